@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -10,8 +11,10 @@ import (
 	"time"
 	"unicode"
 
+	"github.com/google/uuid"
 	"github.com/mcoluomo/RSS-Aggregator/internal/config"
 	"github.com/mcoluomo/RSS-Aggregator/internal/database"
+	"github.com/mcoluomo/RSS-Aggregator/internal/render"
 	"github.com/mcoluomo/RSS-Aggregator/internal/rss"
 )
 
@@ -36,18 +39,29 @@ func AggHandler(s *config.State, cmd Command) error {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		ScrapeFeedsHander(s)
+		if err := ScrapeFeedsHander(s); err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+		}
 	}
 
 	return nil
 }
 
+// feedFetchTimeout bounds one feed fetch: the wait for its turn on the host,
+// the download and saving the items as they arrive. Each item's writes get
+// their own shorter deadline.
+const feedFetchTimeout = time.Minute
+
 func ScrapeFeedsHander(s *config.State) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
 
 	defer cancel()
 
 	nextFeed, err := s.Db.GetNextFeedToFetch(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Println("No feeds due for fetching.")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("%w: failed fetching next feed", err)
 	}
@@ -60,54 +74,536 @@ func ScrapeFeedsHander(s *config.State) error {
 	fmt.Printf("[%s] Fetching feed: %s (%s)\n", now, nextFeed.Name, nextFeed.Url)
 	fmt.Printf("[%s] Marked feed as fetched.\n", now)
 
-	feedDate, err := rss.FetchFeed(ctx, nextFeed.Url)
+	// The fetch gets its own deadline, so waiting for the host's turn neither
+	// eats into the time for the writes below nor fails the feed: a wait that
+	// does not fit is a deferral.
+	fetchCtx, cancelFetch := context.WithTimeout(context.Background(), feedFetchTimeout)
+
+	defer cancelFetch()
+	validators := rss.Validators{ETag: nextFeed.Etag.String, LastModified: nextFeed.LastModified.String}
+	saver := newFeedItemSaver(s, nextFeed, now)
+	result, err := s.Fetcher.Fetch(fetchCtx, nextFeed.Url, validators, func(item rss.RSSItem) error {
+		itemCtx, cancelItem := context.WithTimeout(fetchCtx, 6*time.Second)
+
+		defer cancelItem()
+		saver.save(itemCtx, item)
+		return nil
+	})
+	var deferred *rss.HostDeferredError
+	if errors.As(err, &deferred) {
+		return deferFeedsOnHost(s, deferred, now)
+	}
 	if err != nil {
-		return err
+		recordFeedFailure(s, nextFeed, err, now)
+		return fmt.Errorf("%w: failed fetching feed: %v", err, nextFeed.Name)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	if nextFeed.FailureCount > 0 {
+		if err = s.Db.RecordFeedSuccess(ctx, nextFeed.ID); err != nil {
+			return fmt.Errorf("%w: failed clearing failures of feed: %v", err, nextFeed.Name)
+		}
+		fmt.Printf("[%s] Feed recovered after %d failed fetches.\n", now, nextFeed.FailureCount)
+	}
+
+	for _, redirect := range result.Redirects {
+		fmt.Printf("[%s] Redirected (%d): %s -> %s\n", now, redirect.StatusCode, redirect.From, redirect.To)
+	}
+	if result.PermanentURL != "" && result.PermanentURL != nextFeed.Url {
+		if err = moveFeed(ctx, s, nextFeed, result.PermanentURL); err != nil {
+			fmt.Printf("[%s] ERROR: failed updating URL of moved feed: %v\n", now, err)
+		} else {
+			fmt.Printf("[%s] Feed moved permanently, URL updated: %s -> %s\n", now, nextFeed.Url, result.PermanentURL)
+		}
 	}
-	fmt.Printf("[%s] Fetched %d items from feed.\n", now, len(feedDate.Channel.Item))
+
+	updateValidatorsParams := database.UpdateFeedValidatorsParams{
+		ID:           nextFeed.ID,
+		Etag:         sql.NullString{String: result.Validators.ETag, Valid: result.Validators.ETag != ""},
+		LastModified: sql.NullString{String: result.Validators.LastModified, Valid: result.Validators.LastModified != ""},
+	}
+	if err = s.Db.UpdateFeedValidators(ctx, updateValidatorsParams); err != nil {
+		return fmt.Errorf("%w: failed storing cache validators for feed: %v", err, nextFeed.Name)
+	}
+
+	if result.NotModified {
+		fmt.Printf("[%s] Feed not modified since last fetch.\n", now)
+		return nil
+	}
+
+	feedDate := result.Feed
+	fmt.Printf("[%s] Fetched %d items from feed.\n", now, saver.items)
 
 	log.Println("Fechted FEED")
-	var saved, skipped int
-	for _, feedItem := range feedDate.Channel.Item {
+	if err = updateFeedMetadata(ctx, s, nextFeed, feedDate); err != nil {
+		fmt.Printf("[%s] ERROR: failed updating feed metadata: %v\n", now, err)
+	}
+	recordWebSubHub(ctx, s, nextFeed, feedDate, now)
+	saver.finish()
+	return nil
+}
 
-		fmt.Println("-------------------------------------------")
-		if strings.TrimSpace(feedItem.Title) == "" {
-			skipped += 1
-			continue
+// deferFeedsOnHost holds back every feed on a host that answered 429 or 503
+// until the time it asked for. Being told to wait is not counted as a failure.
+func deferFeedsOnHost(s *config.State, deferred *rss.HostDeferredError, now string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	deferParams := database.DeferFeedsOnHostParams{
+		Until: deferred.Until,
+		Host:  deferred.Host,
+	}
+	deferredFeeds, err := s.Db.DeferFeedsOnHost(ctx, deferParams)
+	if err != nil {
+		return fmt.Errorf("%w: failed deferring feeds on host: %v", err, deferred.Host)
+	}
+	fmt.Printf("[%s] Host %s asked us to back off, %d feeds deferred until %s\n", now, deferred.Host, deferredFeeds, deferred.Until.Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// recordFeedFailure counts a failed fetch and holds the feed back for an
+// exponentially growing delay, disabling it once feedDisableAfter fetches in
+// a row have failed.
+func recordFeedFailure(s *config.State, feed database.Feed, fetchErr error, now string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	failures := feed.FailureCount + 1
+	nextFetch := time.Now().Add(feedBackoff(failures))
+
+	recordFailureParams := database.RecordFeedFailureParams{
+		ID:           feed.ID,
+		FailureCount: failures,
+		LastError:    sql.NullString{String: fetchErr.Error(), Valid: true},
+		NextFetchAt:  sql.NullTime{Time: nextFetch, Valid: true},
+	}
+	if failures >= feedDisableAfter {
+		recordFailureParams.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	if err := s.Db.RecordFeedFailure(ctx, recordFailureParams); err != nil {
+		fmt.Printf("[%s] ERROR: failed recording fetch failure for feed %s: %v\n", now, feed.Name, err)
+		return
+	}
+
+	if recordFailureParams.DisabledAt.Valid {
+		fmt.Printf("[%s] Feed disabled after %d failed fetches: %s (re-enable with: enable %s)\n", now, failures, feed.Name, feed.Url)
+		return
+	}
+	fmt.Printf("[%s] Fetch failure %d for feed %s, next attempt after %s\n", now, failures, feed.Name, nextFetch.Format("2006-01-02 15:04:05"))
+}
+
+const (
+	feedBackoffBase  = time.Minute
+	feedBackoffMax   = 24 * time.Hour
+	feedDisableAfter = 10
+)
+
+// feedBackoff doubles the delay before the next fetch with every consecutive
+// failure, up to feedBackoffMax.
+func feedBackoff(failures int32) time.Duration {
+	delay := feedBackoffBase
+	for i := int32(1); i < failures && delay < feedBackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, feedBackoffMax)
+}
+
+// updateFeedMetadata refreshes what the publisher says about the feed. The
+// name the user gave the feed is left alone.
+func updateFeedMetadata(ctx context.Context, s *config.State, feed database.Feed, feedDate *rss.RSSFeed) error {
+	channel := feedDate.Channel
+	metadataParams := database.UpdateFeedMetadataParams{
+		ID:          feed.ID,
+		Title:       sql.NullString{String: strings.TrimSpace(channel.Title), Valid: strings.TrimSpace(channel.Title) != ""},
+		SiteUrl:     sql.NullString{String: channel.Link, Valid: channel.Link != ""},
+		Description: sql.NullString{String: strings.TrimSpace(channel.Description), Valid: strings.TrimSpace(channel.Description) != ""},
+		Language:    sql.NullString{String: channel.Language, Valid: channel.Language != ""},
+		ImageUrl:    sql.NullString{String: channel.Image, Valid: channel.Image != ""},
+	}
+	return s.Db.UpdateFeedMetadata(ctx, metadataParams)
+}
+
+// feedItemSaver stores the items of a fetched or pushed feed as posts as
+// they are parsed, skipping the ones already saved.
+type feedItemSaver struct {
+	s     *config.State
+	feed  database.Feed
+	now   string
+	dates rss.DateParser
+
+	loaded         bool
+	fingerprints   []database.GetRecentPostFingerprintsRow
+	hasLegacyPosts bool
+
+	items, saved, updated, skipped int
+	savedPosts                     []database.Post
+}
+
+func newFeedItemSaver(s *config.State, feed database.Feed, now string) *feedItemSaver {
+	return &feedItemSaver{
+		s:     s,
+		feed:  feed,
+		now:   now,
+		dates: rss.DateParser{FetchedAt: time.Now()},
+	}
+}
+
+// load looks up what saving needs to know about the feed's recent posts. It
+// waits for the first item, so a feed that is not modified costs no queries.
+func (saver *feedItemSaver) load(ctx context.Context) {
+	s, feed, now := saver.s, saver.feed, saver.now
+	saver.loaded = true
+	fingerprintParams := database.GetRecentPostFingerprintsParams{
+		FeedID: feed.ID,
+		Since:  sql.NullTime{Time: time.Now().Add(-storyClusterWindow), Valid: true},
+	}
+	fingerprints, err := s.Db.GetRecentPostFingerprints(ctx, fingerprintParams)
+	if err != nil {
+		fmt.Printf("[%s] ERROR: failed fetching recent posts for story clustering: %v\n", now, err)
+	}
+	saver.fingerprints = fingerprints
+	// Posts saved before guids were stored wait for their item to adopt its
+	// guid. Once a feed has none left, the per-item update is skipped.
+	hasLegacyPosts, err := s.Db.FeedHasLegacyPosts(ctx, feed.ID)
+	if err != nil {
+		fmt.Printf("[%s] ERROR: failed checking feed for legacy posts: %v\n", now, err)
+	}
+	saver.hasLegacyPosts = hasLegacyPosts
+}
+
+// save stores one item as a post.
+func (saver *feedItemSaver) save(ctx context.Context, feedItem rss.RSSItem) {
+	if !saver.loaded {
+		saver.load(ctx)
+	}
+	s, now := saver.s, saver.now
+	saver.items++
+
+	fmt.Println("-------------------------------------------")
+	if strings.TrimSpace(feedItem.Title) == "" {
+		saver.skipped++
+		return
+	}
+	publicationTime := saver.dates.Parse(feedItem)
+	log.Println("converted date string into time.time")
+	feedItemId := saver.feed.ID
+	log.Println("got feed id")
+
+	guid := feedItem.StableID()
+	canonicalURL := rss.CanonicalURL(feedItem.Link, s.StConfig.TrackingParams())
+	postID := uuid.New()
+	simhash := feedItem.SimHash()
+	clusterID, clustered := storyCluster(saver.fingerprints, simhash)
+	if !clustered {
+		clusterID = postID
+	}
+	if saver.hasLegacyPosts {
+		// Legacy posts were saved with the link as the feed sent it.
+		adoptParams := database.AdoptLegacyPostGuidParams{
+			FeedID: feedItemId,
+			Url:    feedItem.ReceivedLink(),
+			Guid:   guid,
 		}
-		publicationTime, err := time.Parse(time.RFC1123Z, feedItem.PubDate)
-		if err != nil {
-			log.Panicf("%v: failed converting %s into time.time", err, feedItem.PubDate)
+		if err := s.Db.AdoptLegacyPostGuid(ctx, adoptParams); err != nil {
+			fmt.Printf("[%s] ERROR: failed adopting guid for post: %v\n", now, err)
+		}
+	}
+
+	var createPostParams database.CreatePostParams = database.CreatePostParams{
+		ID:           postID,
+		CreatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
+		UpdatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
+		Title:        feedItem.Title,
+		Url:          feedItem.Link,
+		Description:  sql.NullString{String: feedItem.Description, Valid: true},
+		PublishedAt:  sql.NullTime{Time: publicationTime, Valid: true},
+		FeedID:       feedItemId,
+		Content:      sql.NullString{String: feedItem.Content, Valid: feedItem.Content != ""},
+		Guid:         guid,
+		Author:       sql.NullString{String: feedItem.AuthorName(), Valid: feedItem.AuthorName() != ""},
+		CommentsUrl:  sql.NullString{String: feedItem.CommentsURL(), Valid: feedItem.CommentsURL() != ""},
+		ContentHash:  sql.NullString{String: feedItem.ContentHash(), Valid: true},
+		CanonicalUrl: sql.NullString{String: canonicalURL, Valid: canonicalURL != ""},
+		Simhash:      sql.NullInt64{Int64: int64(simhash), Valid: simhash != 0},
+		ClusterID:    uuid.NullUUID{UUID: clusterID, Valid: true},
+	}
+	log.Println("created post params")
+
+	post, err := s.Db.CreatePost(ctx, createPostParams)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			if updatePostContent(ctx, s, saver.feed, createPostParams, now) {
+				saver.updated++
+				return
+			}
+			log.Printf("[%s] Skipped duplicate post: %s\n", now, feedItem.Title)
+			saver.skipped++
+			return
 		}
-		log.Println("converted date string into time.time")
-		feedItemId := nextFeed.ID
-		log.Println("got feed id")
+		fmt.Printf("[%s] ERROR: failed creating post: %v\n", now, err)
+		return
+	}
+	if err = saveEnclosures(ctx, s, post, feedItem.Enclosures); err != nil {
+		fmt.Printf("[%s] ERROR: failed saving media for post %s: %v\n", now, feedItem.Title, err)
+	}
+	for _, category := range feedItem.CategoryNames() {
+		categoryParams := database.CreatePostCategoryParams{PostID: post.ID, Name: category}
+		if err = s.Db.CreatePostCategory(ctx, categoryParams); err != nil {
+			fmt.Printf("[%s] ERROR: failed saving category %s for post %s: %v\n", now, category, feedItem.Title, err)
+		}
+	}
+	if clustered {
+		fmt.Printf("[%s] Saved post: %s (same story as a post in another feed)\n", now, feedItem.Title)
+	} else {
+		fmt.Printf("[%s] Saved post: %s\n", now, feedItem.Title)
+	}
+	saver.saved++
+	saver.savedPosts = append(saver.savedPosts, post)
+}
 
-		var createPostParams database.CreatePostParams = database.CreatePostParams{
-			CreatedAt:   sql.NullTime{Time: time.Now(), Valid: true},
-			UpdatedAt:   sql.NullTime{Time: time.Now(), Valid: true},
-			Title:       feedItem.Title,
-			Url:         feedItem.Link,
-			Description: sql.NullString{String: feedItem.Description, Valid: true},
-			PublishedAt: sql.NullTime{Time: publicationTime, Valid: true},
-			FeedID:      feedItemId,
+// finish reports on the saved items and fetches the full text of the new
+// posts if the feed asks for it.
+func (saver *feedItemSaver) finish() {
+	now := saver.now
+	for _, failure := range saver.dates.Failures {
+		fmt.Printf("[%s] WARNING: unparseable date %q on post: %s (%v), using fetch time\n", now, failure.Raw, failure.Title, failure.Err)
+	}
+	fmt.Printf("[%s] Finished processing feed: %s. %d new posts saved, %d edited posts updated, %d duplicates skipped, %d unparseable dates.\n", now, saver.feed.Name, saver.saved, saver.updated, saver.skipped, len(saver.dates.Failures))
+
+	if saver.feed.FetchFullText {
+		for _, post := range saver.savedPosts {
+			saveFullText(saver.s, post, now)
 		}
-		log.Println("created post params")
+	}
+}
 
-		_, err = s.Db.CreatePost(ctx, createPostParams)
-		if err != nil {
+const (
+	storyClusterWindow      = 72 * time.Hour
+	storyClusterMaxDistance = 6
+)
+
+// storyCluster finds the story a post belongs to among the recent posts of
+// other feeds: that of the post whose SimHash is nearest, if it is within
+// storyClusterMaxDistance bits.
+func storyCluster(fingerprints []database.GetRecentPostFingerprintsRow, simhash uint64) (uuid.UUID, bool) {
+	if simhash == 0 {
+		return uuid.UUID{}, false
+	}
+	var nearest *database.GetRecentPostFingerprintsRow
+	nearestDistance := storyClusterMaxDistance + 1
+	for i, fingerprint := range fingerprints {
+		distance := rss.SimHashDistance(simhash, uint64(fingerprint.Simhash.Int64))
+		if distance < nearestDistance {
+			nearest, nearestDistance = &fingerprints[i], distance
+		}
+	}
+	if nearest == nil {
+		return uuid.UUID{}, false
+	}
+	if nearest.ClusterID.Valid {
+		return nearest.ClusterID.UUID, true
+	}
+	return nearest.ID, true
+}
+
+// updatePostContent compares an already-saved post with the feed's current
+// version of it. When the content hash differs, the stored version is kept in
+// post_revisions and the post is overwritten with the new one. It reports
+// whether the post was updated.
+func updatePostContent(ctx context.Context, s *config.State, feed database.Feed, params database.CreatePostParams, now string) bool {
+	existing, err := s.Db.GetPostByGuid(ctx, database.GetPostByGuidParams{FeedID: feed.ID, Guid: params.Guid})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("[%s] ERROR: failed looking up post %s: %v\n", now, params.Title, err)
+		}
+		return false
+	}
+	if existing.ContentHash == params.ContentHash {
+		return false
+	}
+	if !existing.ContentHash.Valid {
+		// Posts saved before hashes existed have no history to compare
+		// against, so their current version becomes the baseline.
+		hashParams := database.SetPostContentHashParams{ID: existing.ID, ContentHash: params.ContentHash}
+		if err = s.Db.SetPostContentHash(ctx, hashParams); err != nil {
+			fmt.Printf("[%s] ERROR: failed saving content hash for post %s: %v\n", now, params.Title, err)
+		}
+		return false
+	}
+
+	revisionParams := database.CreatePostRevisionParams{
+		PostID:      existing.ID,
+		Title:       existing.Title,
+		Description: existing.Description,
+		Content:     existing.Content,
+		ContentHash: existing.ContentHash,
+	}
+	if err = s.Db.CreatePostRevision(ctx, revisionParams); err != nil {
+		fmt.Printf("[%s] ERROR: failed saving revision of post %s: %v\n", now, params.Title, err)
+		return false
+	}
+	contentParams := database.UpdatePostContentParams{
+		ID:          existing.ID,
+		Title:       params.Title,
+		Description: params.Description,
+		Content:     params.Content,
+		ContentHash: params.ContentHash,
+	}
+	if err = s.Db.UpdatePostContent(ctx, contentParams); err != nil {
+		fmt.Printf("[%s] ERROR: failed updating post %s: %v\n", now, params.Title, err)
+		return false
+	}
+	fmt.Printf("[%s] Updated edited post: %s\n", now, params.Title)
+	return true
+}
+
+// saveFullText downloads a post's page and stores its extracted article.
+// Extraction is best effort: on failure the post keeps the feed's content.
+// Each post gets its own timeout, since pages are fetched one by one.
+func saveFullText(s *config.State, post database.Post, now string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+	defer cancel()
+
+	if post.Url == "" {
+		return
+	}
+	article, err := rss.ExtractArticle(ctx, s.Fetcher, post.Url)
+	if err != nil {
+		fmt.Printf("[%s] WARNING: no full text for post %s: %v\n", now, post.Title, err)
+		return
+	}
+	if article.Canonical != "" {
+		if !saveCanonicalURL(ctx, s, post, rss.CanonicalURL(article.Canonical, s.StConfig.TrackingParams()), now) {
+			return
+		}
+	}
+
+	fullTextParams := database.UpdatePostFullTextParams{
+		ID:             post.ID,
+		FullContent:    sql.NullString{String: article.HTML, Valid: true},
+		FullText:       sql.NullString{String: article.Text, Valid: true},
+		WordCount:      sql.NullInt32{Int32: int32(article.WordCount), Valid: true},
+		ReadingMinutes: sql.NullInt32{Int32: int32(article.ReadingMinutes()), Valid: true},
+	}
+	if err = s.Db.UpdatePostFullText(ctx, fullTextParams); err != nil {
+		fmt.Printf("[%s] ERROR: failed saving full text for post %s: %v\n", now, post.Title, err)
+		return
+	}
+	fmt.Printf("[%s] Saved full text of post: %s (%d words)\n", now, post.Title, article.WordCount)
+}
+
+// saveCanonicalURL replaces a post's canonical URL with the one its page
+// declares. If the feed already holds a post under that URL, the new post is
+// a duplicate of it and is marked as such; saveCanonicalURL then reports
+// false. The duplicate is kept, hidden from browse, so the item's guid stays
+// taken and the next fetch does not save it again.
+func saveCanonicalURL(ctx context.Context, s *config.State, post database.Post, canonicalURL string, now string) bool {
+	if canonicalURL == post.CanonicalUrl.String {
+		return true
+	}
+	canonicalParams := database.UpdatePostCanonicalUrlParams{
+		ID:           post.ID,
+		CanonicalUrl: sql.NullString{String: canonicalURL, Valid: true},
+	}
+	updated, err := s.Db.UpdatePostCanonicalUrl(ctx, canonicalParams)
+	if err != nil {
+		fmt.Printf("[%s] ERROR: failed saving canonical URL for post %s: %v\n", now, post.Title, err)
+		return true
+	}
+	if updated > 0 {
+		return true
+	}
+	duplicateParams := database.MarkPostDuplicateParams{
+		ID:           post.ID,
+		CanonicalUrl: sql.NullString{String: canonicalURL, Valid: true},
+	}
+	if _, err = s.Db.MarkPostDuplicate(ctx, duplicateParams); err != nil {
+		fmt.Printf("[%s] ERROR: failed marking duplicate post %s: %v\n", now, post.Title, err)
+		return true
+	}
+	fmt.Printf("[%s] Hid duplicate post: %s (same canonical URL as an existing post: %s)\n", now, post.Title, canonicalURL)
+	return false
+}
+
+// recordWebSubHub remembers the hub a feed advertises so the websub command
+// can subscribe to it.
+func recordWebSubHub(ctx context.Context, s *config.State, feed database.Feed, feedDate *rss.RSSFeed, now string) {
+	if len(feedDate.Channel.Hubs) == 0 {
+		return
+	}
+
+	topic := feedDate.Channel.Self
+	if topic == "" {
+		topic = feed.Url
+	}
+
+	upsertHubParams := database.UpsertWebSubHubParams{
+		FeedID:    feed.ID,
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		HubUrl:    feedDate.Channel.Hubs[0],
+		TopicUrl:  topic,
+	}
+	if err := s.Db.UpsertWebSubHub(ctx, upsertHubParams); err != nil {
+		fmt.Printf("[%s] ERROR: failed recording WebSub hub: %v\n", now, err)
+		return
+	}
+	fmt.Printf("[%s] WebSub hub found: %s\n", now, upsertHubParams.HubUrl)
+}
+
+// moveFeed points a feed at its new permanent URL and keeps the old one as an
+// alias, so follow and unfollow still resolve it.
+func moveFeed(ctx context.Context, s *config.State, feed database.Feed, newURL string) error {
+	createAliasParams := database.CreateFeedAliasParams{
+		Url:       feed.Url,
+		FeedID:    feed.ID,
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	if err := s.Db.CreateFeedAlias(ctx, createAliasParams); err != nil {
+		return fmt.Errorf("%w: failed creating alias for 【%s】", err, feed.Url)
+	}
+
+	updateUrlParams := database.UpdateFeedUrlParams{
+		ID:  feed.ID,
+		Url: newURL,
+	}
+	if err := s.Db.UpdateFeedUrl(ctx, updateUrlParams); err != nil {
+		return fmt.Errorf("%w: failed updating feed url to 【%s】", err, newURL)
+	}
+	return nil
+}
+
+func saveEnclosures(ctx context.Context, s *config.State, post database.Post, enclosures []rss.Enclosure) error {
+	for _, enclosure := range enclosures {
+		if enclosure.URL == "" {
+			continue
+		}
+
+		createEnclosureParams := database.CreateEnclosureParams{
+			ID:              uuid.New(),
+			CreatedAt:       sql.NullTime{Time: time.Now(), Valid: true},
+			UpdatedAt:       sql.NullTime{Time: time.Now(), Valid: true},
+			PostID:          post.ID,
+			Url:             enclosure.URL,
+			MimeType:        sql.NullString{String: enclosure.Type, Valid: enclosure.Type != ""},
+			Length:          sql.NullInt64{Int64: enclosure.Length, Valid: enclosure.Length > 0},
+			DurationSeconds: sql.NullInt32{Int32: int32(enclosure.Duration.Seconds()), Valid: enclosure.Duration > 0},
+			ThumbnailUrl:    sql.NullString{String: enclosure.Thumbnail, Valid: enclosure.Thumbnail != ""},
+		}
+		if _, err := s.Db.CreateEnclosure(ctx, createEnclosureParams); err != nil {
 			if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-				log.Printf("[%s] Skipped duplicate post: %s\n", now, feedItem.Title)
-				skipped++
 				continue
 			}
-			fmt.Printf("[%s] ERROR: failed creating post: %v\n", now, err)
-			continue
+			return err
 		}
-		fmt.Printf("[%s] Saved post: %s\n", now, feedItem.Title)
-		saved++
 	}
-	fmt.Printf("[%s] Finished processing feed: %s. %d new posts saved, %d duplicates skipped.\n", now, nextFeed.Name, saved, skipped)
 	return nil
 }
 
@@ -116,26 +612,44 @@ func BrowseFeedsHandler(s *config.State, cmd Command) error {
 
 	defer cancel()
 
-	if len(cmd.Args) == 0 {
-		cmd.Args = append(cmd.Args, "2")
-	}
-	if len(cmd.Args) > 1 {
-		return fmt.Errorf("command only takes one argumeant: <command> [limit_if_posts_as_number]")
-	}
-
-	if !containsOnlyNumericDigits(cmd.Args[0]) {
-		return fmt.Errorf("invalid numberic argument: <command> [limit_if_posts_as_number]")
+	limit := "2"
+	var author, category sql.NullString
+	var clusters bool
+	for i := 0; i < len(cmd.Args); i++ {
+		switch arg := cmd.Args[i]; arg {
+		case "--clusters":
+			clusters = true
+		case "--author", "--category":
+			if i+1 == len(cmd.Args) || strings.TrimSpace(cmd.Args[i+1]) == "" {
+				return fmt.Errorf("%s needs a value: <command> [limit_if_posts_as_number] [--category name] [--author name] [--clusters]", arg)
+			}
+			i++
+			filter := sql.NullString{String: strings.TrimSpace(cmd.Args[i]), Valid: true}
+			if arg == "--author" {
+				author = filter
+			} else {
+				category = filter
+			}
+		default:
+			if !containsOnlyNumericDigits(arg) {
+				return fmt.Errorf("invalid numberic argument: <command> [limit_if_posts_as_number] [--category name] [--author name] [--clusters]")
+			}
+			limit = arg
+		}
 	}
 
 	user, _ := s.Db.GetUser(ctx, s.StConfig.Current_user_name)
-	numOfPosts, err := strconv.Atoi(cmd.Args[0])
+	numOfPosts, err := strconv.Atoi(limit)
 	if err != nil {
-		log.Panicf("%v: failed converting %s into integer", err, cmd.Args[0])
+		log.Panicf("%v: failed converting %s into integer", err, limit)
 	}
 
 	getUserPostParams := database.GetUserPostsParams{
-		UserID: user.ID,
-		Limit:  int32(numOfPosts),
+		UserID:   user.ID,
+		Author:   author,
+		Category: category,
+		Clusters: clusters,
+		Limit:    int32(numOfPosts),
 	}
 	userPosts, err := s.Db.GetUserPosts(ctx, getUserPostParams)
 	if err != nil {
@@ -143,18 +657,61 @@ func BrowseFeedsHandler(s *config.State, cmd Command) error {
 	}
 	for i, postRow := range userPosts {
 		fmt.Printf("Post #%d\n", i+1)
-		fmt.Printf("Feed:        %s\n", postRow.Name)
+		fmt.Printf("Feed:        %s\n", postRow.FeedName)
+		if clusters {
+			clusterParams := database.GetClusterFeedNamesParams{
+				ClusterID: postRow.ClusterID,
+				UserID:    user.ID,
+				FeedID:    postRow.FeedID,
+			}
+			alsoIn, err := s.Db.GetClusterFeedNames(ctx, clusterParams)
+			if err != nil {
+				return fmt.Errorf("%w: failed fetching the story of post 【%s】", err, postRow.Title)
+			}
+			if len(alsoIn) > 0 {
+				fmt.Printf("Also in:     %s\n", strings.Join(alsoIn, ", "))
+			}
+		}
 		fmt.Printf("Title:       %s\n", postRow.Title)
+		if postRow.ReadAt.Valid && postRow.ContentUpdatedAt.Valid && postRow.ContentUpdatedAt.Time.After(postRow.ReadAt.Time) {
+			fmt.Printf("Updated:     %s (updated since you read it)\n", postRow.ContentUpdatedAt.Time.Format("2006-01-02 15:04"))
+		}
 		if postRow.PublishedAt.Valid {
 			fmt.Printf("Published:   %s\n", postRow.PublishedAt.Time.Format("2006-01-02"))
 		}
+		if postRow.Author.Valid {
+			fmt.Printf("Author:      %s\n", postRow.Author.String)
+		}
 		fmt.Printf("URL:         %s\n", postRow.Url)
+		categories, err := s.Db.GetPostCategories(ctx, postRow.ID)
+		if err != nil {
+			return fmt.Errorf("%w: failed fetching categories for post 【%s】", err, postRow.Title)
+		}
+		if len(categories) > 0 {
+			fmt.Printf("Categories:  %s\n", strings.Join(categories, ", "))
+		}
+		if postRow.CommentsUrl.Valid {
+			fmt.Printf("Comments:    %s\n", postRow.CommentsUrl.String)
+		}
+		if postRow.ReadingMinutes.Valid {
+			fmt.Printf("Reading:     %d min (%d words)\n", postRow.ReadingMinutes.Int32, postRow.WordCount.Int32)
+		}
+		if postRow.FullContent.Valid {
+			postRow.Description = postRow.FullContent
+		}
 		if postRow.Description.Valid && len(postRow.Description.String) > 0 {
-			desc := postRow.Description.String
-			if len(desc) > 100 {
-				desc = desc[:100] + "..."
-			}
-			fmt.Printf("Description: %s\n", desc)
+			printDescription(postRow.Description.String)
+		}
+		enclosures, err := s.Db.GetEnclosuresForPost(ctx, postRow.ID)
+		if err != nil {
+			return fmt.Errorf("%w: failed fetching media for post 【%s】", err, postRow.Title)
+		}
+		for _, enclosure := range enclosures {
+			printEnclosure(enclosure)
+		}
+		readParams := database.MarkPostReadParams{UserID: user.ID, PostID: postRow.ID}
+		if err = s.Db.MarkPostRead(ctx, readParams); err != nil {
+			return fmt.Errorf("%w: failed marking post 【%s】 as read", err, postRow.Title)
 		}
 		fmt.Println("------------------------------------------------------------")
 	}
@@ -162,6 +719,59 @@ func BrowseFeedsHandler(s *config.State, cmd Command) error {
 	return nil
 }
 
+// descriptionWidth is the column browse wraps descriptions at, and
+// descriptionExcerpt how many columns of a description it shows.
+const (
+	descriptionWidth   = 76
+	descriptionExcerpt = 400
+)
+
+// printDescription renders a post's HTML description as wrapped text,
+// followed by the targets of the links that made it into the excerpt.
+func printDescription(description string) {
+	body, links := render.HTML(description, descriptionWidth)
+	if body == "" {
+		return
+	}
+	excerpt := render.Truncate(body, descriptionExcerpt)
+
+	fmt.Println("Description:")
+	for _, line := range strings.Split(excerpt, "\n") {
+		if line == "" {
+			fmt.Println()
+			continue
+		}
+		fmt.Printf("  %s\n", line)
+	}
+	for i, link := range links {
+		marker := "[" + strconv.Itoa(i+1) + "]"
+		if strings.Contains(excerpt, marker) {
+			fmt.Printf("  %s %s\n", marker, link)
+		}
+	}
+}
+
+func printEnclosure(enclosure database.Enclosure) {
+	var details []string
+	if enclosure.MimeType.Valid {
+		details = append(details, enclosure.MimeType.String)
+	}
+	if enclosure.DurationSeconds.Valid {
+		details = append(details, (time.Duration(enclosure.DurationSeconds.Int32) * time.Second).String())
+	}
+	if enclosure.Length.Valid {
+		details = append(details, fmt.Sprintf("%.1f MB", float64(enclosure.Length.Int64)/1e6))
+	}
+
+	fmt.Printf("Media:       %s\n", enclosure.Url)
+	if len(details) > 0 {
+		fmt.Printf("             %s\n", strings.Join(details, ", "))
+	}
+	if enclosure.ThumbnailUrl.Valid {
+		fmt.Printf("Thumbnail:   %s\n", enclosure.ThumbnailUrl.String)
+	}
+}
+
 func containsOnlyNumericDigits(numericStr string) bool {
 	for _, char := range numericStr {
 		if !unicode.IsDigit(char) {
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mcoluomo/RSS-Aggregator/internal/config"
+	"github.com/mcoluomo/RSS-Aggregator/internal/database"
+	"github.com/mcoluomo/RSS-Aggregator/internal/rss"
+)
+
+func TestAggregateTwiceSavesNothingNew(t *testing.T) {
+	article := strings.Repeat(`<p>A paragraph long enough to count as the body of the article, with words that keep going for a while.</p>`, 3)
+	site := fakeSite{
+		"https://example.com/feed.xml": `<rss version="2.0"><channel><title>Example</title><link>https://example.com/</link>
+<item><title>Original</title><link>https://example.com/post</link><guid>1</guid></item>
+<item><title>Mirror</title><link>https://example.com/amp/post</link><guid>2</guid></item>
+</channel></rss>`,
+		// Both pages declare the same canonical URL, so the second post is a
+		// duplicate of the first.
+		"https://example.com/post":     `<html><head><link rel="canonical" href="https://example.com/post"></head><body><article>` + article + `</article></body></html>`,
+		"https://example.com/amp/post": `<html><head><link rel="canonical" href="https://example.com/post"></head><body><article>` + article + `</article></body></html>`,
+	}
+	db := &fakeDB{}
+	s := &config.State{
+		Db:       database.New(sql.OpenDB(db)),
+		StConfig: &config.Config{},
+		Fetcher:  site,
+	}
+	feed := database.Feed{ID: uuid.New(), Name: "Example", Url: "https://example.com/feed.xml", FetchFullText: true}
+
+	aggregate := func() {
+		ctx := context.Background()
+		saver := newFeedItemSaver(s, feed, "test")
+		_, err := s.Fetcher.Fetch(ctx, feed.Url, rss.Validators{}, func(item rss.RSSItem) error {
+			saver.save(ctx, item)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Fetch: %v", err)
+		}
+		saver.finish()
+	}
+
+	aggregate()
+	if db.created != 2 {
+		t.Fatalf("first pass created %d posts, want 2", db.created)
+	}
+	original, mirror := db.posts[0], db.posts[1]
+	if mirror["duplicate_of"] != original["id"] {
+		t.Errorf("mirror duplicate_of = %v, want the original's id %v", mirror["duplicate_of"], original["id"])
+	}
+
+	aggregate()
+	if db.created != 2 {
+		t.Errorf("second pass created %d more posts, want none", db.created-2)
+	}
+	if len(db.posts) != 2 {
+		t.Errorf("%d posts stored, want the 2 of the first pass", len(db.posts))
+	}
+}
+
+func TestStoryCluster(t *testing.T) {
+	const simhash = uint64(0xf0f0_f0f0_f0f0_f0f0)
+	// flip returns simhash with its n lowest bits flipped.
+	flip := func(n int) sql.NullInt64 {
+		return sql.NullInt64{Int64: int64(simhash ^ (1<<n - 1)), Valid: true}
+	}
+	cluster, other := uuid.New(), uuid.New()
+	tests := []struct {
+		name         string
+		fingerprints []database.GetRecentPostFingerprintsRow
+		simhash      uint64
+		want         uuid.UUID
+		clustered    bool
+	}{
+		{"at the threshold", []database.GetRecentPostFingerprintsRow{
+			{ID: other, ClusterID: uuid.NullUUID{UUID: cluster, Valid: true}, Simhash: flip(storyClusterMaxDistance)},
+		}, simhash, cluster, true},
+		{"over the threshold", []database.GetRecentPostFingerprintsRow{
+			{ID: other, ClusterID: uuid.NullUUID{UUID: cluster, Valid: true}, Simhash: flip(storyClusterMaxDistance + 1)},
+		}, simhash, uuid.UUID{}, false},
+		{"nearest wins", []database.GetRecentPostFingerprintsRow{
+			{ID: uuid.New(), ClusterID: uuid.NullUUID{UUID: uuid.New(), Valid: true}, Simhash: flip(5)},
+			{ID: other, ClusterID: uuid.NullUUID{UUID: cluster, Valid: true}, Simhash: flip(2)},
+		}, simhash, cluster, true},
+		{"post without a cluster", []database.GetRecentPostFingerprintsRow{
+			{ID: other, Simhash: flip(1)},
+		}, simhash, other, true},
+		{"no words", []database.GetRecentPostFingerprintsRow{
+			{ID: other, Simhash: sql.NullInt64{Valid: true}},
+		}, 0, uuid.UUID{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, clustered := storyCluster(tt.fingerprints, tt.simhash)
+			if got != tt.want || clustered != tt.clustered {
+				t.Errorf("storyCluster = %v, %v, want %v, %v", got, clustered, tt.want, tt.clustered)
+			}
+		})
+	}
+}
+
+// fakeSite serves a feed and its pages from memory.
+type fakeSite map[string]string
+
+func (f fakeSite) Fetch(ctx context.Context, feedURL string, validators rss.Validators, visit rss.ItemFunc) (*rss.FetchResult, error) {
+	body, ok := f[feedURL]
+	if !ok {
+		return nil, fmt.Errorf("no feed at %s", feedURL)
+	}
+	feed, err := rss.ParseFeed("application/rss+xml", feedURL, strings.NewReader(body), visit)
+	if err != nil {
+		return nil, err
+	}
+	return &rss.FetchResult{Feed: feed}, nil
+}
+
+func (f fakeSite) FetchPage(ctx context.Context, pageURL string) (string, []byte, error) {
+	body, ok := f[pageURL]
+	if !ok {
+		return "", nil, fmt.Errorf("no page at %s", pageURL)
+	}
+	return "text/html; charset=utf-8", []byte(body), nil
+}
+
+// fakeDB is an in-memory stand-in for the posts table, answering the sqlc
+// queries that saving a feed runs. Rows are keyed by column name.
+type fakeDB struct {
+	posts   []map[string]driver.Value
+	created int
+}
+
+var (
+	queryName   = regexp.MustCompile(`-- name: (\w+)`)
+	insertCols  = regexp.MustCompile(`INSERT INTO \w+ \(([^)]*)\)`)
+	resultCols  = regexp.MustCompile(`(?:RETURNING|SELECT) ([\w, ]+?)(?: FROM|\n|$)`)
+	errNotFaked = errors.New("query not faked")
+)
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errNotFaked }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errNotFaked }
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	db := c.db
+	switch name := queryName.FindStringSubmatch(query)[1]; name {
+	case "CreatePost":
+		post := map[string]driver.Value{}
+		for i, column := range strings.Split(insertCols.FindStringSubmatch(query)[1], ", ") {
+			post[column] = args[i].Value
+		}
+		for _, other := range db.posts {
+			if other["feed_id"] == post["feed_id"] && (other["guid"] == post["guid"] ||
+				post["canonical_url"] != nil && other["canonical_url"] == post["canonical_url"]) {
+				return nil, errors.New(`duplicate key value violates unique constraint "posts_feed_id_guid_key"`)
+			}
+		}
+		db.posts = append(db.posts, post)
+		db.created++
+		return newFakeRows(query, post), nil
+	case "GetPostByGuid":
+		for _, post := range db.posts {
+			if post["feed_id"] == args[0].Value && post["guid"] == args[1].Value {
+				return newFakeRows(query, post), nil
+			}
+		}
+		return newFakeRows(query), nil
+	case "FeedHasLegacyPosts":
+		return &fakeRows{columns: []string{"exists"}, rows: [][]driver.Value{{false}}}, nil
+	case "GetRecentPostFingerprints":
+		return newFakeRows(query), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errNotFaked, name)
+	}
+}
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	db := c.db
+	switch queryName.FindStringSubmatch(query)[1] {
+	case "UpdatePostCanonicalUrl":
+		post := db.post(args[0].Value)
+		for _, other := range db.posts {
+			if other["feed_id"] == post["feed_id"] && other["canonical_url"] == args[1].Value && other["id"] != post["id"] {
+				return driver.RowsAffected(0), nil
+			}
+		}
+		post["canonical_url"] = args[1].Value
+		return driver.RowsAffected(1), nil
+	case "MarkPostDuplicate":
+		post := db.post(args[0].Value)
+		for _, original := range db.posts {
+			if original["feed_id"] == post["feed_id"] && original["canonical_url"] == args[1].Value && original["id"] != post["id"] {
+				post["duplicate_of"] = original["id"]
+				return driver.RowsAffected(1), nil
+			}
+		}
+		return driver.RowsAffected(0), nil
+	}
+	// Writes the test does not look at, such as full text and categories.
+	return driver.RowsAffected(1), nil
+}
+
+func (db *fakeDB) post(id driver.Value) map[string]driver.Value {
+	for _, post := range db.posts {
+		if post["id"] == id {
+			return post
+		}
+	}
+	return map[string]driver.Value{}
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// newFakeRows returns posts with the columns the query selects.
+func newFakeRows(query string, posts ...map[string]driver.Value) *fakeRows {
+	rows := &fakeRows{columns: strings.Split(resultCols.FindStringSubmatch(query)[1], ", ")}
+	for _, post := range posts {
+		row := make([]driver.Value, len(rows.columns))
+		for i, column := range rows.columns {
+			row[i] = post[column]
+		}
+		rows.rows = append(rows.rows, row)
+	}
+	return rows
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -8,15 +8,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/mcoluomo/RSS-Aggregator/internal/config"
 	"github.com/mcoluomo/RSS-Aggregator/internal/database"
 )
 
 func FollowHandler(s *config.State, cmd Command, user database.User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
-
-	defer cancel()
-
 	if len(cmd.Args) == 0 {
 		return fmt.Errorf("Please provide the valid argument for this command: <command> 【[url]】")
 	}
@@ -29,11 +26,15 @@ func FollowHandler(s *config.State, cmd Command, user database.User) error {
 		return fmt.Errorf("Please provide valid url: <command> 【[url]】")
 	}
 
-	feedId, err := s.Db.GetFeedId(ctx, cmd.Args[0])
+	feedId, err := lookupFeedId(s, cmd.Args[0])
 	if err != nil {
-		return fmt.Errorf("%w: failed fetching feed id", err)
+		return err
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
 	user, _ = s.Db.GetUser(ctx, s.StConfig.Current_user_name)
 
 	feedFollowParams := database.CreateFeedFollowParams{
@@ -124,3 +125,37 @@ func UnfollowFeedFollow(s *config.State, cmd Command, user database.User) error
 
 	return nil
 }
+
+// lookupFeedId finds the feed stored under url. When there is none, url is
+// treated as a website and the feed it advertises is looked up instead.
+func lookupFeedId(s *config.State, url string) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	feedId, err := s.Db.GetFeedId(ctx, url)
+	if err == nil {
+		return feedId, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("%w: failed fetching feed id", err)
+	}
+
+	feedURL, err := discoverFeedURL(s, url)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	feedId, err = s.Db.GetFeedId(ctx, feedURL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("No feed found with that URL. Please add the feed first.")
+		}
+		return uuid.Nil, fmt.Errorf("%w: failed fetching feed id", err)
+	}
+	return feedId, nil
+}
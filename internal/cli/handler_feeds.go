@@ -1,15 +1,20 @@
 package cli
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mcoluomo/RSS-Aggregator/internal/config"
 	"github.com/mcoluomo/RSS-Aggregator/internal/database"
+	"github.com/mcoluomo/RSS-Aggregator/internal/render"
+	"github.com/mcoluomo/RSS-Aggregator/internal/rss"
 )
 
 func PrintFeedsHandler(s *config.State, cmd Command) error {
@@ -49,15 +54,127 @@ func printFeed(feed database.Feed, user database.User) {
 	fmt.Printf("* Created:       %v\n", feed.CreatedAt.Time)
 	fmt.Printf("* Updated:       %v\n", feed.UpdatedAt.Time)
 	fmt.Printf("* Name:          %s\n", feed.Name)
+	if feed.Title.Valid && feed.Title.String != feed.Name {
+		fmt.Printf("* Title:         %s\n", feed.Title.String)
+	}
 	fmt.Printf("* URL:           %s\n", feed.Url)
+	if feed.SiteUrl.Valid {
+		fmt.Printf("* Site:          %s\n", feed.SiteUrl.String)
+	}
+	if feed.Description.Valid {
+		fmt.Printf("* Description:   %s\n", render.Truncate(feed.Description.String, 100))
+	}
+	if feed.Language.Valid {
+		fmt.Printf("* Language:      %s\n", feed.Language.String)
+	}
+	if feed.ImageUrl.Valid {
+		fmt.Printf("* Image:         %s\n", feed.ImageUrl.String)
+	}
 	fmt.Printf("* User:          %s\n", user.Name)
+	if feed.FetchFullText {
+		fmt.Printf("* Full text:     on\n")
+	}
+	if feed.DisabledAt.Valid {
+		fmt.Printf("* Disabled:      %v\n", feed.DisabledAt.Time)
+	}
+	if feed.FailureCount > 0 {
+		fmt.Printf("* Failures:      %d\n", feed.FailureCount)
+		fmt.Printf("* Last error:    %s\n", feed.LastError.String)
+	}
 }
 
-func AddFeedHandler(s *config.State, cmd Command, user database.User) error {
+func FullTextHandler(s *config.State, cmd Command) error {
+	if len(cmd.Args) != 2 || (cmd.Args[1] != "on" && cmd.Args[1] != "off") {
+		return fmt.Errorf("Please provide the valid arguments for this command: <command> [url] [on|off]")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	feedId, err := s.Db.GetFeedId(ctx, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("%w: no feed found with url 【%s】", err, cmd.Args[0])
+	}
+
+	fullTextParams := database.SetFeedFullTextParams{
+		ID:            feedId,
+		FetchFullText: cmd.Args[1] == "on",
+	}
+	if _, err = s.Db.SetFeedFullText(ctx, fullTextParams); err != nil {
+		return fmt.Errorf("%w: failed updating feed 【%s】", err, cmd.Args[0])
+	}
+
+	fmt.Println("----------------------------------------")
+	if fullTextParams.FetchFullText {
+		fmt.Printf("full text of new posts from 【%s】 will be fetched\n", cmd.Args[0])
+	} else {
+		fmt.Printf("full text of new posts from 【%s】 will no longer be fetched\n", cmd.Args[0])
+	}
+	fmt.Println("----------------------------------------")
+	return nil
+}
+
+func DisabledFeedsHandler(s *config.State, cmd Command) error {
+	if len(cmd.Args) > 0 {
+		return fmt.Errorf("command does not accept any arguments")
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
 
 	defer cancel()
 
+	feeds, err := s.Db.GetDisabledFeeds(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: failed fetching disabled feeds", err)
+	}
+
+	if len(feeds) == 0 {
+		fmt.Println("No disabled feeds.")
+		return nil
+	}
+	fmt.Printf("Found %d disabled feeds:\n", len(feeds))
+
+	for _, feed := range feeds {
+		user, err := s.Db.GetUserById(ctx, feed.UserID)
+		if err != nil {
+			return fmt.Errorf("failed getting user: %w", err)
+		}
+		printFeed(feed, user)
+	}
+	fmt.Println("----------------------------------------")
+	fmt.Println("re-enable a feed with: enable <feed url>")
+	return nil
+}
+
+func EnableFeedHandler(s *config.State, cmd Command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("Please provide the valid argument for this command: <command> [url]")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	feedId, err := s.Db.GetFeedId(ctx, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("%w: no feed found with url 【%s】", err, cmd.Args[0])
+	}
+
+	enabled, err := s.Db.EnableFeed(ctx, feedId)
+	if err != nil {
+		return fmt.Errorf("%w: failed enabling feed 【%s】", err, cmd.Args[0])
+	}
+	if enabled == 0 {
+		return fmt.Errorf("no feed found with url 【%s】", cmd.Args[0])
+	}
+
+	fmt.Println("----------------------------------------")
+	fmt.Printf("feed 【%s】 is enabled and will be fetched on the next agg tick\n", cmd.Args[0])
+	fmt.Println("----------------------------------------")
+	return nil
+}
+
+func AddFeedHandler(s *config.State, cmd Command, user database.User) error {
 	if len(cmd.Args) == 0 {
 		return fmt.Errorf("Please provide the valid argument for this command: <command> [url]")
 	}
@@ -78,6 +195,16 @@ func AddFeedHandler(s *config.State, cmd Command, user database.User) error {
 		return fmt.Errorf("Please provide valid url: <command> [feedName] 【[url]】")
 	}
 
+	// Discovery may wait on the user, so it runs before the database timeout starts.
+	feedURL, err := discoverFeedURL(s, cmd.Args[1])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
 	user, _ = s.Db.GetUser(ctx, s.StConfig.Current_user_name)
 
 	newFeed := database.CreateFeedParams{
@@ -85,7 +212,7 @@ func AddFeedHandler(s *config.State, cmd Command, user database.User) error {
 		CreatedAt:     sql.NullTime{Time: time.Now(), Valid: true},
 		UpdatedAt:     sql.NullTime{Time: time.Now(), Valid: true},
 		Name:          cmd.Args[0],
-		Url:           cmd.Args[1],
+		Url:           feedURL,
 		UserID:        user.ID,
 		LastFetchedAt: sql.NullTime{Time: time.Now(), Valid: true},
 	}
@@ -118,3 +245,48 @@ func AddFeedHandler(s *config.State, cmd Command, user database.User) error {
 
 	return nil
 }
+
+// discoverFeedURL turns a website or feed URL into a feed URL. When the site
+// advertises more than one feed the user is asked to pick one.
+func discoverFeedURL(s *config.State, siteURL string) (string, error) {
+	// A local file has no page to discover feeds from.
+	if strings.HasPrefix(siteURL, "file:") {
+		return siteURL, nil
+	}
+
+	candidates, err := rss.DiscoverFeeds(context.Background(), s.Fetcher, siteURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed discovering feeds at 【%s】", err, siteURL)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("no feeds found at 【%s】", siteURL)
+	case 1:
+		if candidates[0].URL != siteURL {
+			fmt.Printf("Discovered feed: %s\n", candidates[0].URL)
+		}
+		return candidates[0].URL, nil
+	}
+
+	fmt.Printf("Found %d feeds at 【%s】:\n", len(candidates), siteURL)
+	for i, candidate := range candidates {
+		fmt.Printf("  %d) %s %s\n", i+1, candidate.URL, candidate.Title)
+	}
+	fmt.Print("Pick a feed [1]: ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return candidates[0].URL, nil
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return candidates[0].URL, nil
+	}
+
+	choice, err := strconv.Atoi(answer)
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return "", fmt.Errorf("invalid choice 【%s】: pick a number between 1 and %d", answer, len(candidates))
+	}
+	return candidates[choice-1].URL, nil
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mcoluomo/RSS-Aggregator/internal/config"
+	"github.com/mcoluomo/RSS-Aggregator/internal/rss"
+)
+
+// ValidateHandler checks a feed by URL or local file and lists what gator
+// finds wrong with it, most severe first. It fails when there are errors, so
+// it can be scripted.
+func ValidateHandler(s *config.State, cmd Command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: validate <feed url|file>")
+	}
+	// The timeout covers a conditional re-request and the wait between the
+	// two requests to the host.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+
+	defer cancel()
+
+	report, err := rss.Validate(ctx, s.Fetcher, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("%w: failed validating feed 【%s】", err, cmd.Args[0])
+	}
+
+	fmt.Printf("Feed:         %s\n", report.URL)
+	if report.StatusCode != 0 {
+		fmt.Printf("HTTP status:  %d\n", report.StatusCode)
+	}
+	if report.ContentType != "" {
+		fmt.Printf("Content-Type: %s\n", report.ContentType)
+	}
+	if report.Format != "" {
+		fmt.Printf("Format:       %s\n", report.Format)
+		fmt.Printf("Encoding:     %s\n", report.Encoding)
+		fmt.Printf("Size:         %d bytes, %d items\n", report.Size, report.Items)
+	}
+	fmt.Println("------------------------------------------------------------")
+
+	for _, severity := range []rss.Severity{rss.SeverityError, rss.SeverityWarning, rss.SeverityInfo} {
+		for _, diagnostic := range report.Diagnostics {
+			if diagnostic.Severity != severity {
+				continue
+			}
+			where := "feed"
+			if diagnostic.Item > 0 {
+				where = fmt.Sprintf("item %d", diagnostic.Item)
+			}
+			fmt.Printf("%-8s %-10s %-9s %s\n", strings.ToUpper(severity.String()), "["+diagnostic.Check+"]", where, diagnostic.Message)
+		}
+	}
+
+	errorCount := report.Count(rss.SeverityError)
+	fmt.Printf("%d errors, %d warnings, %d notes\n", errorCount, report.Count(rss.SeverityWarning), report.Count(rss.SeverityInfo))
+	if errorCount > 0 {
+		return fmt.Errorf("feed 【%s】 has %d errors", report.URL, errorCount)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mcoluomo/RSS-Aggregator/internal/config"
+	"github.com/mcoluomo/RSS-Aggregator/internal/database"
+	"github.com/mcoluomo/RSS-Aggregator/internal/rss"
+)
+
+const (
+	webSubLease = 10 * 24 * time.Hour
+	// Leases expiring sooner than webSubRenewBefore are renewed on each
+	// pass, and a request still awaiting verification is not repeated
+	// for webSubRetryAfter.
+	webSubRenewInterval = 10 * time.Minute
+	webSubRenewBefore   = time.Hour
+	webSubRetryAfter    = 5 * time.Minute
+)
+
+// WebSubHandler runs the WebSub subscriber: an HTTP listener for hub
+// verifications and pushed content, and a loop that subscribes to the hubs
+// agg has found and renews their leases.
+func WebSubHandler(s *config.State, cmd Command) error {
+	if len(cmd.Args) != 2 {
+		return fmt.Errorf("Please provide the valid arguments for this command: <command> [listen_addr] [callback_base_url]")
+	}
+
+	listenAddr := cmd.Args[0]
+	callbackBase := strings.TrimRight(cmd.Args[1], "/")
+	if !isValidUrl(callbackBase) {
+		return fmt.Errorf("Please provide valid url: <command> [listen_addr] 【[callback_base_url]】")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /websub/{feedID}", func(w http.ResponseWriter, r *http.Request) {
+		handleWebSubVerification(s, w, r)
+	})
+	mux.HandleFunc("POST /websub/{feedID}", func(w http.ResponseWriter, r *http.Request) {
+		handleWebSubPush(s, w, r)
+	})
+
+	server := &http.Server{
+		Addr:              listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	fmt.Printf("Listening for WebSub callbacks on %s (%s/websub/...)\n", listenAddr, callbackBase)
+
+	renewWebSubSubscriptions(s, callbackBase)
+	ticker := time.NewTicker(webSubRenewInterval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-serveErr:
+			return fmt.Errorf("%w: websub listener stopped", err)
+		case <-ticker.C:
+			renewWebSubSubscriptions(s, callbackBase)
+		}
+	}
+}
+
+func renewWebSubSubscriptions(s *config.State, callbackBase string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	subscriptions, err := s.Db.GetWebSubSubscriptions(ctx)
+	if err != nil {
+		fmt.Printf("ERROR: failed fetching WebSub subscriptions: %v\n", err)
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		if subscription.LeaseExpiresAt.Valid && time.Until(subscription.LeaseExpiresAt.Time) > webSubRenewBefore {
+			continue
+		}
+		if subscription.RequestedAt.Valid && time.Since(subscription.RequestedAt.Time) < webSubRetryAfter {
+			continue
+		}
+		if err := requestWebSubSubscription(s, subscription, callbackBase); err != nil {
+			fmt.Printf("ERROR: failed subscribing to %s at hub %s: %v\n", subscription.TopicUrl, subscription.HubUrl, err)
+			continue
+		}
+		fmt.Printf("Requested WebSub subscription: %s (hub %s)\n", subscription.TopicUrl, subscription.HubUrl)
+	}
+}
+
+func requestWebSubSubscription(s *config.State, subscription database.WebsubSubscription, callbackBase string) error {
+	secret := subscription.Secret.String
+	if !subscription.Secret.Valid {
+		secret = rand.Text()
+	}
+	callback := callbackBase + "/websub/" + subscription.FeedID.String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	// The secret is stored before the hub hears of it, since the hub may
+	// verify the subscription or push content before Subscribe returns. A
+	// failed request is retried after webSubRetryAfter like any other.
+	markRequestedParams := database.MarkWebSubRequestedParams{
+		FeedID: subscription.FeedID,
+		Secret: sql.NullString{String: secret, Valid: true},
+	}
+	if err := s.Db.MarkWebSubRequested(ctx, markRequestedParams); err != nil {
+		return fmt.Errorf("%w: failed storing subscription secret", err)
+	}
+
+	return rss.Subscribe(context.Background(), subscription.HubUrl, subscription.TopicUrl, callback, secret, webSubLease)
+}
+
+// handleWebSubVerification answers the hub's intent verification by echoing
+// the challenge for subscriptions we asked for.
+func handleWebSubVerification(s *config.State, w http.ResponseWriter, r *http.Request) {
+	subscription, err := webSubSubscriptionFor(s, r)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	switch query.Get("hub.mode") {
+	case "subscribe":
+		if query.Get("hub.topic") != subscription.TopicUrl {
+			http.NotFound(w, r)
+			return
+		}
+
+		lease := webSubLease
+		if seconds, err := strconv.Atoi(query.Get("hub.lease_seconds")); err == nil && seconds > 0 {
+			lease = time.Duration(seconds) * time.Second
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+		defer cancel()
+
+		confirmLeaseParams := database.ConfirmWebSubLeaseParams{
+			FeedID:         subscription.FeedID,
+			LeaseExpiresAt: sql.NullTime{Time: time.Now().Add(lease), Valid: true},
+		}
+		if err := s.Db.ConfirmWebSubLease(ctx, confirmLeaseParams); err != nil {
+			http.Error(w, "failed storing lease", http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Printf("WebSub subscription verified: %s (lease %v)\n", subscription.TopicUrl, lease)
+		w.Write([]byte(query.Get("hub.challenge")))
+	case "denied":
+		fmt.Printf("WebSub subscription denied: %s: %s\n", subscription.TopicUrl, query.Get("hub.reason"))
+		w.WriteHeader(http.StatusOK)
+	default:
+		// We never unsubscribe, so any other request is not ours.
+		http.NotFound(w, r)
+	}
+}
+
+// handleWebSubPush ingests content distributed by the hub. Content with a
+// missing or invalid signature is acknowledged but dropped, as the spec
+// requires. So is content for a subscription without a secret or a lease the
+// hub has confirmed, which no genuine hub can have signed, and content that
+// arrives after the lease has expired.
+func handleWebSubPush(s *config.State, w http.ResponseWriter, r *http.Request) {
+	subscription, err := webSubSubscriptionFor(s, r)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, s.StConfig.MaxFeedBytes()))
+	if err != nil {
+		http.Error(w, "failed reading body", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	if !subscription.Secret.Valid || !subscription.LeaseExpiresAt.Valid || !subscription.LeaseExpiresAt.Time.After(time.Now()) {
+		fmt.Printf("[%s] WARNING: dropped WebSub push without an active subscription for: %s\n", now, subscription.TopicUrl)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if !rss.VerifySignature(r.Header.Get("X-Hub-Signature"), subscription.Secret.String, body) {
+		fmt.Printf("[%s] WARNING: dropped WebSub push with invalid signature for: %s\n", now, subscription.TopicUrl)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	feed, err := s.Db.GetFeedById(ctx, subscription.FeedID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	saver := newFeedItemSaver(s, feed, now)
+	_, err = rss.ParseFeed(r.Header.Get("Content-Type"), subscription.TopicUrl, bytes.NewReader(body), func(item rss.RSSItem) error {
+		itemCtx, cancelItem := context.WithTimeout(context.Background(), 6*time.Second)
+
+		defer cancelItem()
+		saver.save(itemCtx, item)
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("[%s] ERROR: failed parsing WebSub push for %s: %v\n", now, subscription.TopicUrl, err)
+		http.Error(w, "unparseable feed", http.StatusBadRequest)
+		return
+	}
+
+	fmt.Printf("[%s] WebSub push for feed: %s (%d items)\n", now, feed.Name, saver.items)
+	saver.finish()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func webSubSubscriptionFor(s *config.State, r *http.Request) (database.WebsubSubscription, error) {
+	feedID, err := uuid.Parse(r.PathValue("feedID"))
+	if err != nil {
+		return database.WebsubSubscription{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+
+	defer cancel()
+
+	return s.Db.GetWebSubSubscription(ctx, feedID)
+}
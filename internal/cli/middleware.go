@@ -48,6 +48,9 @@ func MiddlewareLoggedIn(handler func(s *config.State, cmd Command, user database
 func isValidUrl(str string) bool {
 	u, err := url.Parse(str)
 
+	if err == nil && u.Scheme == "file" {
+		return u.Path != "" || u.Opaque != ""
+	}
 	return err == nil && u.Scheme != "" && u.Host != ""
 }
 
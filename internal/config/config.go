@@ -5,11 +5,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/mcoluomo/RSS-Aggregator/internal/rss"
 )
 
 type Config struct {
-	Db_url            string `json:"db_url"`
-	Current_user_name string `json:"current_user_name"`
+	Db_url            string   `json:"db_url"`
+	Current_user_name string   `json:"current_user_name"`
+	Max_feed_bytes    int64    `json:"max_feed_bytes,omitempty"`
+	Min_host_interval string   `json:"min_host_interval,omitempty"`
+	Respect_robots    bool     `json:"respect_robots,omitempty"`
+	Fixtures_dir      string   `json:"fixtures_dir,omitempty"`
+	Record_fixtures   bool     `json:"record_fixtures,omitempty"`
+	Tracking_params   []string `json:"tracking_params,omitempty"`
 }
 
 func Read() (Config, error) {
@@ -32,6 +41,38 @@ func Read() (Config, error) {
 	return config, nil
 }
 
+// HTTPFetcher returns a fetcher with the configured feed size limit, host
+// request spacing and robots.txt setting.
+func (config *Config) HTTPFetcher() (*rss.HTTPFetcher, error) {
+	fetcher := rss.NewHTTPFetcher()
+	fetcher.MaxBodySize = config.MaxFeedBytes()
+	if config.Min_host_interval != "" {
+		interval, err := time.ParseDuration(config.Min_host_interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min_host_interval in config: %w", err)
+		}
+		fetcher.MinHostInterval = interval
+	}
+	fetcher.RespectRobots = config.Respect_robots
+	return fetcher, nil
+}
+
+// MaxFeedBytes is max_feed_bytes, or rss.DefaultMaxBodySize when unset.
+func (config *Config) MaxFeedBytes() int64 {
+	if config.Max_feed_bytes > 0 {
+		return config.Max_feed_bytes
+	}
+	return rss.DefaultMaxBodySize
+}
+
+// TrackingParams is tracking_params, or rss.DefaultTrackingParams when unset.
+func (config *Config) TrackingParams() []string {
+	if len(config.Tracking_params) > 0 {
+		return config.Tracking_params
+	}
+	return rss.DefaultTrackingParams
+}
+
 func getConfigFilePath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
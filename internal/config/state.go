@@ -1,8 +1,12 @@
 package config
 
-import "github.com/mcoluomo/RSS-Aggregator/internal/database"
+import (
+	"github.com/mcoluomo/RSS-Aggregator/internal/database"
+	"github.com/mcoluomo/RSS-Aggregator/internal/rss"
+)
 
 type State struct {
 	Db       *database.Queries
 	StConfig *Config
+	Fetcher  rss.Fetcher
 }
@@ -8,6 +8,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -23,7 +24,7 @@ VALUES (
     $6,
     $7
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, failure_count, last_error, next_fetch_at, disabled_at, fetch_full_text, title, site_url, description, language, image_url
 `
 
 type CreateFeedParams struct {
@@ -55,12 +56,148 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FailureCount,
+		&i.LastError,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.FetchFullText,
+		&i.Title,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const deferFeedsOnHost = `-- name: DeferFeedsOnHost :execrows
+UPDATE feeds
+SET
+  next_fetch_at = GREATEST(COALESCE(next_fetch_at, $1::timestamp), $1::timestamp),
+  updated_at = NOW()
+WHERE disabled_at IS NULL
+  AND lower(substring(url from '^[A-Za-z][A-Za-z0-9+.-]*://([^/?#]+)')) = lower($2::text)
+`
+
+type DeferFeedsOnHostParams struct {
+	Until time.Time
+	Host  string
+}
+
+func (q *Queries) DeferFeedsOnHost(ctx context.Context, arg DeferFeedsOnHostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deferFeedsOnHost, arg.Until, arg.Host)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enableFeed = `-- name: EnableFeed :execrows
+UPDATE feeds
+SET
+  failure_count = 0,
+  last_error = NULL,
+  next_fetch_at = NULL,
+  disabled_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) EnableFeed(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enableFeed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getDisabledFeeds = `-- name: GetDisabledFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, failure_count, last_error, next_fetch_at, disabled_at, fetch_full_text, title, site_url, description, language, image_url FROM feeds
+WHERE disabled_at IS NOT NULL
+ORDER BY disabled_at DESC
+`
+
+func (q *Queries) GetDisabledFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getDisabledFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FailureCount,
+			&i.LastError,
+			&i.NextFetchAt,
+			&i.DisabledAt,
+			&i.FetchFullText,
+			&i.Title,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedById = `-- name: GetFeedById :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, failure_count, last_error, next_fetch_at, disabled_at, fetch_full_text, title, site_url, description, language, image_url FROM feeds WHERE id = $1
+`
+
+func (q *Queries) GetFeedById(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedById, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FailureCount,
+		&i.LastError,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.FetchFullText,
+		&i.Title,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
 	)
 	return i, err
 }
 
 const getFeedId = `-- name: GetFeedId :one
-SELECT feeds.id FROM feeds WHERE url = $1
+SELECT feeds.id FROM feeds WHERE feeds.url = $1
+UNION
+SELECT feed_aliases.feed_id FROM feed_aliases WHERE feed_aliases.url = $1
+LIMIT 1
 `
 
 func (q *Queries) GetFeedId(ctx context.Context, url string) (uuid.UUID, error) {
@@ -71,7 +208,7 @@ func (q *Queries) GetFeedId(ctx context.Context, url string) (uuid.UUID, error)
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, failure_count, last_error, next_fetch_at, disabled_at, fetch_full_text, title, site_url, description, language, image_url FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -91,6 +228,18 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FailureCount,
+			&i.LastError,
+			&i.NextFetchAt,
+			&i.DisabledAt,
+			&i.FetchFullText,
+			&i.Title,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -106,8 +255,10 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 }
 
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, failure_count, last_error, next_fetch_at, disabled_at, fetch_full_text, title, site_url, description, language, image_url
 FROM feeds
+WHERE disabled_at IS NULL
+  AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
 ORDER BY
   last_fetched_at ASC NULLS FIRST,
   id ASC
@@ -125,6 +276,18 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FailureCount,
+		&i.LastError,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.FetchFullText,
+		&i.Title,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
 	)
 	return i, err
 }
@@ -141,3 +304,139 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
 	return err
 }
+
+const recordFeedFailure = `-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET
+  failure_count = $2,
+  last_error = $3,
+  next_fetch_at = $4,
+  disabled_at = $5,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type RecordFeedFailureParams struct {
+	ID           uuid.UUID
+	FailureCount int32
+	LastError    sql.NullString
+	NextFetchAt  sql.NullTime
+	DisabledAt   sql.NullTime
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFailure,
+		arg.ID,
+		arg.FailureCount,
+		arg.LastError,
+		arg.NextFetchAt,
+		arg.DisabledAt,
+	)
+	return err
+}
+
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET
+  failure_count = 0,
+  last_error = NULL,
+  next_fetch_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) RecordFeedSuccess(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess, id)
+	return err
+}
+
+const setFeedFullText = `-- name: SetFeedFullText :execrows
+UPDATE feeds
+SET
+  fetch_full_text = $2,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedFullTextParams struct {
+	ID            uuid.UUID
+	FetchFullText bool
+}
+
+func (q *Queries) SetFeedFullText(ctx context.Context, arg SetFeedFullTextParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFullText, arg.ID, arg.FetchFullText)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET
+  title = $2,
+  site_url = $3,
+  description = $4,
+  language = $5,
+  image_url = $6,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedMetadataParams struct {
+	ID          uuid.UUID
+	Title       sql.NullString
+	SiteUrl     sql.NullString
+	Description sql.NullString
+	Language    sql.NullString
+	ImageUrl    sql.NullString
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata,
+		arg.ID,
+		arg.Title,
+		arg.SiteUrl,
+		arg.Description,
+		arg.Language,
+		arg.ImageUrl,
+	)
+	return err
+}
+
+const updateFeedValidators = `-- name: UpdateFeedValidators :exec
+UPDATE feeds
+SET
+  etag = $2,
+  last_modified = $3,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedValidatorsParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedValidators(ctx context.Context, arg UpdateFeedValidatorsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedValidators, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const updateFeedUrl = `-- name: UpdateFeedUrl :exec
+UPDATE feeds
+SET
+  url = $2,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedUrlParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) UpdateFeedUrl(ctx context.Context, arg UpdateFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedUrl, arg.ID, arg.Url)
+	return err
+}
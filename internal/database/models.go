@@ -6,10 +6,23 @@ package database
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+type Enclosure struct {
+	ID              uuid.UUID
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+	Url             string
+	MimeType        sql.NullString
+	Length          sql.NullInt64
+	DurationSeconds sql.NullInt32
+	ThumbnailUrl    sql.NullString
+	PostID          uuid.UUID
+}
+
 type Feed struct {
 	ID            uuid.UUID
 	CreatedAt     sql.NullTime
@@ -18,6 +31,24 @@ type Feed struct {
 	Url           string
 	UserID        uuid.UUID
 	LastFetchedAt sql.NullTime
+	Etag          sql.NullString
+	LastModified  sql.NullString
+	FailureCount  int32
+	LastError     sql.NullString
+	NextFetchAt   sql.NullTime
+	DisabledAt    sql.NullTime
+	FetchFullText bool
+	Title         sql.NullString
+	SiteUrl       sql.NullString
+	Description   sql.NullString
+	Language      sql.NullString
+	ImageUrl      sql.NullString
+}
+
+type FeedAlias struct {
+	Url       string
+	FeedID    uuid.UUID
+	CreatedAt sql.NullTime
 }
 
 type FeedFollow struct {
@@ -28,13 +59,50 @@ type FeedFollow struct {
 }
 
 type Post struct {
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	Title            string
+	Url              string
+	Description      sql.NullString
+	PublishedAt      sql.NullTime
+	FeedID           uuid.UUID
+	Content          sql.NullString
+	ID               uuid.UUID
+	Guid             string
+	FullContent      sql.NullString
+	FullText         sql.NullString
+	WordCount        sql.NullInt32
+	ReadingMinutes   sql.NullInt32
+	Author           sql.NullString
+	CommentsUrl      sql.NullString
+	ContentHash      sql.NullString
+	ContentUpdatedAt sql.NullTime
+	CanonicalUrl     sql.NullString
+	Simhash          sql.NullInt64
+	ClusterID        uuid.NullUUID
+	DuplicateOf      uuid.NullUUID
+}
+
+type PostCategory struct {
+	PostID    uuid.UUID
+	Name      string
+	CreatedAt time.Time
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostRevision struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	PostID      uuid.UUID
 	Title       string
-	Url         string
 	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	Content     sql.NullString
+	ContentHash sql.NullString
 }
 
 type User struct {
@@ -43,3 +111,14 @@ type User struct {
 	UpdatedAt sql.NullTime
 	Name      string
 }
+
+type WebsubSubscription struct {
+	FeedID         uuid.UUID
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	HubUrl         string
+	TopicUrl       string
+	Secret         sql.NullString
+	RequestedAt    sql.NullTime
+	LeaseExpiresAt sql.NullTime
+}
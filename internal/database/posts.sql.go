@@ -12,8 +12,25 @@ import (
 	"github.com/google/uuid"
 )
 
+const adoptLegacyPostGuid = `-- name: AdoptLegacyPostGuid :exec
+UPDATE posts
+SET guid = $3
+WHERE feed_id = $1 AND url = $2 AND guid LIKE 'legacy:%'
+`
+
+type AdoptLegacyPostGuidParams struct {
+	FeedID uuid.UUID
+	Url    string
+	Guid   string
+}
+
+func (q *Queries) AdoptLegacyPostGuid(ctx context.Context, arg AdoptLegacyPostGuidParams) error {
+	_, err := q.db.ExecContext(ctx, adoptLegacyPostGuid, arg.FeedID, arg.Url, arg.Guid)
+	return err
+}
+
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content, guid, author, comments_url, content_hash, canonical_url, simhash, cluster_id)
 VALUES (
     $1,
     $2,
@@ -21,23 +38,42 @@ VALUES (
     $4,
     $5,
     $6,
-    $7
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16
 )
-RETURNING created_at, updated_at, title, url, description, published_at, feed_id
+RETURNING created_at, updated_at, title, url, description, published_at, feed_id, content, id, guid, full_content, full_text, word_count, reading_minutes, author, comments_url, content_hash, content_updated_at, canonical_url, simhash, cluster_id, duplicate_of
 `
 
 type CreatePostParams struct {
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	ID           uuid.UUID
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.UUID
+	Content      sql.NullString
+	Guid         string
+	Author       sql.NullString
+	CommentsUrl  sql.NullString
+	ContentHash  sql.NullString
+	CanonicalUrl sql.NullString
+	Simhash      sql.NullInt64
+	ClusterID    uuid.NullUUID
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
 	row := q.db.QueryRowContext(ctx, createPost,
+		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Title,
@@ -45,7 +81,103 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Content,
+		arg.Guid,
+		arg.Author,
+		arg.CommentsUrl,
+		arg.ContentHash,
+		arg.CanonicalUrl,
+		arg.Simhash,
+		arg.ClusterID,
+	)
+	var i Post
+	err := row.Scan(
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Content,
+		&i.ID,
+		&i.Guid,
+		&i.FullContent,
+		&i.FullText,
+		&i.WordCount,
+		&i.ReadingMinutes,
+		&i.Author,
+		&i.CommentsUrl,
+		&i.ContentHash,
+		&i.ContentUpdatedAt,
+		&i.CanonicalUrl,
+		&i.Simhash,
+		&i.ClusterID,
+		&i.DuplicateOf,
 	)
+	return i, err
+}
+
+const feedHasLegacyPosts = `-- name: FeedHasLegacyPosts :one
+SELECT EXISTS(SELECT 1 FROM posts WHERE feed_id = $1 AND guid LIKE 'legacy:%')
+`
+
+func (q *Queries) FeedHasLegacyPosts(ctx context.Context, feedID uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, feedHasLegacyPosts, feedID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const getClusterFeedNames = `-- name: GetClusterFeedNames :many
+SELECT DISTINCT feeds.name FROM posts
+JOIN feeds ON posts.feed_id = feeds.id
+JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE posts.cluster_id = $1 AND feed_follows.user_id = $2 AND posts.feed_id <> $3
+ORDER BY feeds.name
+`
+
+type GetClusterFeedNamesParams struct {
+	ClusterID uuid.NullUUID
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+}
+
+func (q *Queries) GetClusterFeedNames(ctx context.Context, arg GetClusterFeedNamesParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getClusterFeedNames, arg.ClusterID, arg.UserID, arg.FeedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByGuid = `-- name: GetPostByGuid :one
+SELECT created_at, updated_at, title, url, description, published_at, feed_id, content, id, guid, full_content, full_text, word_count, reading_minutes, author, comments_url, content_hash, content_updated_at, canonical_url, simhash, cluster_id, duplicate_of FROM posts
+WHERE feed_id = $1 AND guid = $2
+`
+
+type GetPostByGuidParams struct {
+	FeedID uuid.UUID
+	Guid   string
+}
+
+func (q *Queries) GetPostByGuid(ctx context.Context, arg GetPostByGuidParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByGuid, arg.FeedID, arg.Guid)
 	var i Post
 	err := row.Scan(
 		&i.CreatedAt,
@@ -55,51 +187,144 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Content,
+		&i.ID,
+		&i.Guid,
+		&i.FullContent,
+		&i.FullText,
+		&i.WordCount,
+		&i.ReadingMinutes,
+		&i.Author,
+		&i.CommentsUrl,
+		&i.ContentHash,
+		&i.ContentUpdatedAt,
+		&i.CanonicalUrl,
+		&i.Simhash,
+		&i.ClusterID,
+		&i.DuplicateOf,
 	)
 	return i, err
 }
 
+const getRecentPostFingerprints = `-- name: GetRecentPostFingerprints :many
+SELECT id, cluster_id, simhash FROM posts
+WHERE feed_id <> $1 AND simhash IS NOT NULL AND created_at >= $2 AND duplicate_of IS NULL
+`
+
+type GetRecentPostFingerprintsParams struct {
+	FeedID uuid.UUID
+	Since  sql.NullTime
+}
+
+type GetRecentPostFingerprintsRow struct {
+	ID        uuid.UUID
+	ClusterID uuid.NullUUID
+	Simhash   sql.NullInt64
+}
+
+func (q *Queries) GetRecentPostFingerprints(ctx context.Context, arg GetRecentPostFingerprintsParams) ([]GetRecentPostFingerprintsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostFingerprints, arg.FeedID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRecentPostFingerprintsRow
+	for rows.Next() {
+		var i GetRecentPostFingerprintsRow
+		if err := rows.Scan(&i.ID, &i.ClusterID, &i.Simhash); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserPosts = `-- name: GetUserPosts :many
-SELECT DISTINCT posts.created_at, posts.updated_at, title, posts.url, description, published_at, posts.feed_id, id, feeds.created_at, feeds.updated_at, name, feeds.url, feeds.user_id, last_fetched_at, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id FROM posts
+SELECT posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.id, posts.guid, posts.full_content, posts.full_text, posts.word_count, posts.reading_minutes, posts.author, posts.comments_url, posts.content_hash, posts.content_updated_at, posts.canonical_url, posts.simhash, posts.cluster_id, posts.duplicate_of, feeds.name AS feed_name, post_reads.read_at FROM posts
 JOIN feeds ON posts.feed_id = feeds.id
 
 JOIN feed_follows ON feed_follows.feed_id = feeds.id
 
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+
 WHERE feed_follows.user_id = $1
+  AND posts.duplicate_of IS NULL
+  AND ($2::text IS NULL OR posts.author ILIKE '%' || $2 || '%')
+  AND ($3::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_categories
+    WHERE post_categories.post_id = posts.id
+      AND lower(post_categories.name) = lower($3)
+  ))
+  AND (NOT $4::boolean OR NOT EXISTS (
+    SELECT 1 FROM posts AS sibling
+    JOIN feed_follows AS sibling_follows ON sibling_follows.feed_id = sibling.feed_id
+    WHERE sibling_follows.user_id = feed_follows.user_id
+      AND sibling.cluster_id = posts.cluster_id
+      AND sibling.duplicate_of IS NULL
+      AND (sibling.published_at > posts.published_at
+        OR (sibling.published_at = posts.published_at AND sibling.id < posts.id))
+      AND ($2::text IS NULL OR sibling.author ILIKE '%' || $2 || '%')
+      AND ($3::text IS NULL OR EXISTS (
+        SELECT 1 FROM post_categories
+        WHERE post_categories.post_id = sibling.id
+          AND lower(post_categories.name) = lower($3)
+      ))
+  ))
 
-ORDER BY published_at DESC
+ORDER BY posts.published_at DESC
 
-LIMIT $2
+LIMIT $5
 `
 
 type GetUserPostsParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID   uuid.UUID
+	Author   sql.NullString
+	Category sql.NullString
+	Clusters bool
+	Limit    int32
 }
 
 type GetUserPostsRow struct {
-	CreatedAt     sql.NullTime
-	UpdatedAt     sql.NullTime
-	Title         string
-	Url           string
-	Description   sql.NullString
-	PublishedAt   sql.NullTime
-	FeedID        uuid.UUID
-	ID            uuid.UUID
-	CreatedAt_2   sql.NullTime
-	UpdatedAt_2   sql.NullTime
-	Name          string
-	Url_2         string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
-	CreatedAt_3   sql.NullTime
-	UpdatedAt_3   sql.NullTime
-	UserID_2      uuid.UUID
-	FeedID_2      uuid.UUID
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	Title            string
+	Url              string
+	Description      sql.NullString
+	PublishedAt      sql.NullTime
+	FeedID           uuid.UUID
+	Content          sql.NullString
+	ID               uuid.UUID
+	Guid             string
+	FullContent      sql.NullString
+	FullText         sql.NullString
+	WordCount        sql.NullInt32
+	ReadingMinutes   sql.NullInt32
+	Author           sql.NullString
+	CommentsUrl      sql.NullString
+	ContentHash      sql.NullString
+	ContentUpdatedAt sql.NullTime
+	CanonicalUrl     sql.NullString
+	Simhash          sql.NullInt64
+	ClusterID        uuid.NullUUID
+	DuplicateOf      uuid.NullUUID
+	FeedName         string
+	ReadAt           sql.NullTime
 }
 
 func (q *Queries) GetUserPosts(ctx context.Context, arg GetUserPostsParams) ([]GetUserPostsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getUserPosts, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getUserPosts,
+		arg.UserID,
+		arg.Author,
+		arg.Category,
+		arg.Clusters,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -115,17 +340,23 @@ func (q *Queries) GetUserPosts(ctx context.Context, arg GetUserPostsParams) ([]G
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Content,
 			&i.ID,
-			&i.CreatedAt_2,
-			&i.UpdatedAt_2,
-			&i.Name,
-			&i.Url_2,
-			&i.UserID,
-			&i.LastFetchedAt,
-			&i.CreatedAt_3,
-			&i.UpdatedAt_3,
-			&i.UserID_2,
-			&i.FeedID_2,
+			&i.Guid,
+			&i.FullContent,
+			&i.FullText,
+			&i.WordCount,
+			&i.ReadingMinutes,
+			&i.Author,
+			&i.CommentsUrl,
+			&i.ContentHash,
+			&i.ContentUpdatedAt,
+			&i.CanonicalUrl,
+			&i.Simhash,
+			&i.ClusterID,
+			&i.DuplicateOf,
+			&i.FeedName,
+			&i.ReadAt,
 		); err != nil {
 			return nil, err
 		}
@@ -139,3 +370,122 @@ func (q *Queries) GetUserPosts(ctx context.Context, arg GetUserPostsParams) ([]G
 	}
 	return items, nil
 }
+
+const markPostDuplicate = `-- name: MarkPostDuplicate :execrows
+UPDATE posts
+SET duplicate_of = original.id, updated_at = NOW()
+FROM posts AS original
+WHERE posts.id = $1 AND original.feed_id = posts.feed_id AND original.canonical_url = $2 AND original.id <> posts.id
+`
+
+type MarkPostDuplicateParams struct {
+	ID           uuid.UUID
+	CanonicalUrl sql.NullString
+}
+
+func (q *Queries) MarkPostDuplicate(ctx context.Context, arg MarkPostDuplicateParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markPostDuplicate, arg.ID, arg.CanonicalUrl)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setPostContentHash = `-- name: SetPostContentHash :exec
+UPDATE posts
+SET content_hash = $2
+WHERE id = $1
+`
+
+type SetPostContentHashParams struct {
+	ID          uuid.UUID
+	ContentHash sql.NullString
+}
+
+func (q *Queries) SetPostContentHash(ctx context.Context, arg SetPostContentHashParams) error {
+	_, err := q.db.ExecContext(ctx, setPostContentHash, arg.ID, arg.ContentHash)
+	return err
+}
+
+const updatePostCanonicalUrl = `-- name: UpdatePostCanonicalUrl :execrows
+UPDATE posts
+SET canonical_url = $2, updated_at = NOW()
+WHERE id = $1 AND NOT EXISTS (
+    SELECT 1 FROM posts AS other
+    WHERE other.feed_id = posts.feed_id AND other.canonical_url = $2 AND other.id <> posts.id
+)
+`
+
+type UpdatePostCanonicalUrlParams struct {
+	ID           uuid.UUID
+	CanonicalUrl sql.NullString
+}
+
+func (q *Queries) UpdatePostCanonicalUrl(ctx context.Context, arg UpdatePostCanonicalUrlParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updatePostCanonicalUrl, arg.ID, arg.CanonicalUrl)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updatePostContent = `-- name: UpdatePostContent :exec
+UPDATE posts
+SET
+  title = $2,
+  description = $3,
+  content = $4,
+  content_hash = $5,
+  content_updated_at = NOW(),
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdatePostContentParams struct {
+	ID          uuid.UUID
+	Title       string
+	Description sql.NullString
+	Content     sql.NullString
+	ContentHash sql.NullString
+}
+
+func (q *Queries) UpdatePostContent(ctx context.Context, arg UpdatePostContentParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostContent,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.Content,
+		arg.ContentHash,
+	)
+	return err
+}
+
+const updatePostFullText = `-- name: UpdatePostFullText :exec
+UPDATE posts
+SET
+  full_content = $2,
+  full_text = $3,
+  word_count = $4,
+  reading_minutes = $5,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdatePostFullTextParams struct {
+	ID             uuid.UUID
+	FullContent    sql.NullString
+	FullText       sql.NullString
+	WordCount      sql.NullInt32
+	ReadingMinutes sql.NullInt32
+}
+
+func (q *Queries) UpdatePostFullText(ctx context.Context, arg UpdatePostFullTextParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostFullText,
+		arg.ID,
+		arg.FullContent,
+		arg.FullText,
+		arg.WordCount,
+		arg.ReadingMinutes,
+	)
+	return err
+}
@@ -0,0 +1,191 @@
+// Package render turns the HTML stored with posts into text for the
+// terminal.
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTML renders an HTML fragment as plain text wrapped to limit columns:
+// blocks become paragraphs separated by blank lines, list items get bullets
+// or numbers, quotes get "> " and links become numbered footnote markers.
+// The link targets are returned in marker order.
+func HTML(fragment string, limit int) (string, []string) {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return strings.Join(wrap(fragment, limit, "", ""), "\n"), nil
+	}
+
+	r := &renderer{limit: limit}
+	for _, n := range nodes {
+		r.node(n)
+	}
+	r.endBlock()
+	return strings.Join(r.lines, "\n"), r.links
+}
+
+type renderer struct {
+	limit int
+	lines []string
+	links []string
+
+	// inline collects the text of the paragraph being built. prefix starts
+	// its first line and indent the ones after it.
+	inline strings.Builder
+	prefix string
+	indent string
+
+	// gap asks for a blank line before the next paragraph.
+	gap bool
+	// depth counts the lists being rendered, so nested ones stay compact.
+	depth int
+}
+
+// flush writes the pending paragraph, if any.
+func (r *renderer) flush() {
+	text := r.inline.String()
+	r.inline.Reset()
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	if r.gap && len(r.lines) > 0 {
+		r.lines = append(r.lines, "")
+	}
+	r.gap = false
+	r.lines = append(r.lines, wrap(text, r.limit, r.prefix, r.indent)...)
+	r.prefix = r.indent
+}
+
+// endBlock flushes and separates what follows with a blank line.
+func (r *renderer) endBlock() {
+	r.flush()
+	r.gap = true
+}
+
+func (r *renderer) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		r.node(child)
+	}
+}
+
+func (r *renderer) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.inline.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Head, atom.Title, atom.Svg:
+	case atom.Br:
+		r.flush()
+	case atom.A:
+		r.children(n)
+		if href := strings.TrimSpace(attr(n, "href")); href != "" && !strings.HasPrefix(href, "#") {
+			r.links = append(r.links, href)
+			r.inline.WriteString("[" + strconv.Itoa(len(r.links)) + "]")
+		}
+	case atom.Img:
+		// 1x1 images are tracking pixels.
+		if attr(n, "width") == "1" || attr(n, "height") == "1" {
+			return
+		}
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			r.inline.WriteString(" [image: " + alt + "] ")
+		} else {
+			r.inline.WriteString(" [image] ")
+		}
+	case atom.Ul, atom.Ol:
+		if r.depth > 0 {
+			r.flush()
+			r.list(n)
+			return
+		}
+		r.endBlock()
+		r.list(n)
+		r.endBlock()
+	case atom.Blockquote:
+		r.endBlock()
+		prefix, indent := r.prefix, r.indent
+		r.prefix, r.indent = indent+"> ", indent+"> "
+		r.children(n)
+		r.endBlock()
+		r.prefix, r.indent = prefix, indent
+	case atom.Pre:
+		r.endBlock()
+		if len(r.lines) > 0 {
+			r.lines = append(r.lines, "")
+		}
+		for _, line := range strings.Split(strings.Trim(nodeText(n), "\n"), "\n") {
+			r.lines = append(r.lines, r.indent+"    "+line)
+		}
+		r.gap = true
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Figure, atom.Figcaption, atom.Table, atom.Tr, atom.Dl, atom.Dt, atom.Dd, atom.Hr:
+		r.endBlock()
+		r.children(n)
+		r.endBlock()
+	case atom.Td, atom.Th:
+		r.children(n)
+		r.inline.WriteString(" ")
+	default:
+		r.children(n)
+	}
+}
+
+// list renders the items of a ul or ol one per line, nested lists indented
+// under their item.
+func (r *renderer) list(n *html.Node) {
+	prefix, indent := r.prefix, r.indent
+	r.depth++
+	number := 0
+	for item := n.FirstChild; item != nil; item = item.NextSibling {
+		if item.Type != html.ElementNode || item.DataAtom != atom.Li {
+			continue
+		}
+		number++
+		marker := "• "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + ". "
+		}
+		r.flush()
+		r.prefix, r.indent = indent+marker, indent+strings.Repeat(" ", StringWidth(marker))
+		r.children(item)
+		r.flush()
+		r.gap = false
+	}
+	r.depth--
+	r.prefix, r.indent = prefix, indent
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(nodeText(child))
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// RuneWidth is the number of terminal columns a rune takes: two for wide
+// East Asian characters, none for combining marks and control characters.
+func RuneWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), unicode.Is(unicode.Cf, r), unicode.IsControl(r):
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// StringWidth is the number of terminal columns s takes on one line.
+func StringWidth(s string) int {
+	var n int
+	for _, r := range s {
+		n += RuneWidth(r)
+	}
+	return n
+}
+
+// Truncate shortens s to at most limit columns, counting each line break as
+// one, and marks the cut with an ellipsis. It never splits a rune and keeps
+// combining marks with the character they belong to.
+func Truncate(s string, limit int) string {
+	if columns(s) <= limit {
+		return s
+	}
+	budget := limit - 1
+	var used int
+	for i, r := range s {
+		w := RuneWidth(r)
+		if r == '\n' {
+			w = 1
+		}
+		if used+w > budget {
+			return strings.TrimRightFunc(s[:i], unicode.IsSpace) + "…"
+		}
+		used += w
+	}
+	return s
+}
+
+func columns(s string) int {
+	return StringWidth(s) + strings.Count(s, "\n")
+}
+
+// wrap breaks text into lines of at most limit columns at spaces. The first
+// line starts with prefix and the others with indent; a word longer than a
+// line, such as a run of CJK text, is broken between runes.
+func wrap(text string, limit int, prefix, indent string) []string {
+	var lines []string
+	line := prefix
+	lineWidth := StringWidth(prefix)
+	empty := true
+	for _, word := range strings.Fields(text) {
+		wordWidth := StringWidth(word)
+		if wordWidth > limit-StringWidth(indent) {
+			if !empty {
+				line += " "
+				lineWidth++
+			}
+			for _, r := range word {
+				w := RuneWidth(r)
+				if lineWidth+w > limit && lineWidth > StringWidth(indent) {
+					lines = append(lines, line)
+					line, lineWidth = indent, StringWidth(indent)
+				}
+				line += string(r)
+				lineWidth += w
+			}
+			empty = false
+			continue
+		}
+		if !empty && lineWidth+1+wordWidth > limit {
+			lines = append(lines, line)
+			line, lineWidth, empty = indent, StringWidth(indent), true
+		}
+		if !empty {
+			line += " "
+			lineWidth++
+		}
+		line += word
+		lineWidth += wordWidth
+		empty = false
+	}
+	if !empty {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		limit int
+		want  string
+	}{
+		{"fits", "short", 10, "short"},
+		{"cut mid word", "hello world", 8, "hello w…"},
+		{"cut at space", "hello world", 7, "hello…"},
+		{"multibyte", "héllo wörld", 8, "héllo w…"},
+		{"combining marks", "e\u0301e\u0301e\u0301e\u0301", 3, "e\u0301e\u0301…"},
+		{"wide", "日本語テキスト", 7, "日本語…"},
+		{"wide rune over the limit", "日本語テキスト", 6, "日本…"},
+		{"wide exact fit", "日本", 4, "日本"},
+		{"line break counts", "ab\ncd", 4, "ab…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.s, tt.limit)
+			if got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.limit, got, tt.want)
+			}
+			if columns(got) > tt.limit {
+				t.Errorf("Truncate(%q, %d) takes %d columns", tt.s, tt.limit, columns(got))
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		limit          int
+		prefix, indent string
+		want           []string
+	}{
+		{"words", "the quick brown fox", 10, "", "", []string{"the quick", "brown fox"}},
+		{"prefix and indent", "one two three", 9, "- ", "  ", []string{"- one two", "  three"}},
+		{"multibyte", "héllo wörld çà", 11, "", "", []string{"héllo wörld", "çà"}},
+		{"wide word broken between runes", "日本語のテキスト", 6, "", "", []string{"日本語", "のテキ", "スト"}},
+		{"wide word after a short one", "a 日本語テキスト", 5, "", "", []string{"a 日", "本語", "テキ", "スト"}},
+		{"empty", "  ", 10, "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrap(tt.text, tt.limit, tt.prefix, tt.indent)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("wrap(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+			for _, line := range got {
+				if StringWidth(line) > tt.limit {
+					t.Errorf("line %q is %d columns wide, over %d", line, StringWidth(line), tt.limit)
+				}
+			}
+		})
+	}
+}
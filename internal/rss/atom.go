@@ -0,0 +1,218 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atomEntry is an Atom entry. Its fields are tagged with the Atom namespace
+// so that extension elements of the same name, such as <dc:title> or
+// <media:title>, cannot overwrite them.
+type atomEntry struct {
+	Base       string         `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	ID         string         `xml:"http://www.w3.org/2005/Atom id"`
+	Title      string         `xml:"http://www.w3.org/2005/Atom title"`
+	Links      []atomLink     `xml:"http://www.w3.org/2005/Atom link"`
+	Updated    string         `xml:"http://www.w3.org/2005/Atom updated"`
+	Published  string         `xml:"http://www.w3.org/2005/Atom published"`
+	Summary    atomText       `xml:"http://www.w3.org/2005/Atom summary"`
+	Content    atomText       `xml:"http://www.w3.org/2005/Atom content"`
+	Authors    []atomPerson   `xml:"http://www.w3.org/2005/Atom author"`
+	Categories []atomCategory `xml:"http://www.w3.org/2005/Atom category"`
+	mediaElements
+}
+
+type atomPerson struct {
+	Name  string `xml:"http://www.w3.org/2005/Atom name"`
+	Email string `xml:"http://www.w3.org/2005/Atom email"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// atomText holds an Atom text construct. xhtml content is kept as markup,
+// text and html content arrive already unescaped in Text.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// plain reports whether the construct is plain text, which is the default.
+func (t atomText) plain() bool {
+	return t.Type == "" || t.Type == "text"
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+func parseAtom(decoder *xml.Decoder, sink *itemSink) error {
+	rssFeed := sink.feed
+	var links []atomLink
+	var icon, logo string
+	err := eachChild(decoder, func(start xml.StartElement) error {
+		if start.Name.Space != atomNamespace {
+			return decoder.Skip()
+		}
+		switch start.Name.Local {
+		case "title":
+			return decoder.DecodeElement(&rssFeed.Channel.Title, &start)
+		case "subtitle":
+			return decoder.DecodeElement(&rssFeed.Channel.Description, &start)
+		case "icon":
+			return decoder.DecodeElement(&icon, &start)
+		case "logo":
+			return decoder.DecodeElement(&logo, &start)
+		case "link":
+			var link atomLink
+			if err := decoder.DecodeElement(&link, &start); err != nil {
+				return err
+			}
+			links = append(links, link)
+			return nil
+		case "entry":
+			var entry atomEntry
+			if err := decoder.DecodeElement(&entry, &start); err != nil {
+				return err
+			}
+			return sink.add(entry.item())
+		}
+		return decoder.Skip()
+	})
+	if err != nil {
+		return fmt.Errorf("%w: failed to decode atom data", err)
+	}
+
+	rssFeed.Channel.Link = alternateLink(links)
+	rssFeed.Channel.Image = strings.TrimSpace(icon)
+	if rssFeed.Channel.Image == "" {
+		rssFeed.Channel.Image = strings.TrimSpace(logo)
+	}
+	for _, link := range links {
+		rssFeed.addLinkRel(link.Rel, link.Href)
+	}
+	return nil
+}
+
+func (entry atomEntry) item() RSSItem {
+	content, plainContent := entry.Content.String(), entry.Content.plain()
+	description, plainDescription := entry.Summary.String(), entry.Summary.plain()
+	if description == "" {
+		description, plainDescription = content, plainContent
+	}
+
+	pubDate := entry.Published
+	if pubDate == "" {
+		pubDate = entry.Updated
+	}
+
+	return RSSItem{
+		Title:       entry.Title,
+		Link:        alternateLink(entry.Links),
+		Description: description,
+		Content:     content,
+		PubDate:     pubDate,
+		Guid:        strings.TrimSpace(entry.ID),
+		Author:      entry.authorNames(),
+		Categories:  entry.categoryNames(),
+		Comments:    repliesLink(entry.Links),
+		Enclosures:  entry.mediaElements.enclosures(enclosureLinks(entry.Links)),
+		Base:        entry.Base,
+
+		plainDescription: plainDescription,
+		plainContent:     plainContent,
+	}
+}
+
+func (entry atomEntry) authorNames() string {
+	var names []string
+	for _, author := range entry.Authors {
+		name := strings.TrimSpace(author.Name)
+		if name == "" {
+			name = strings.TrimSpace(author.Email)
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// categoryNames prefers a category's human-readable label over its term.
+func (entry atomEntry) categoryNames() []string {
+	var names []string
+	for _, category := range entry.Categories {
+		if category.Label != "" {
+			names = append(names, category.Label)
+		} else {
+			names = append(names, category.Term)
+		}
+	}
+	return names
+}
+
+// repliesLink is the entry's rel="replies" link (RFC 4685), preferring an
+// HTML page over a comments feed.
+func repliesLink(links []atomLink) string {
+	var fallback string
+	for _, link := range links {
+		if link.Rel != "replies" {
+			continue
+		}
+		if link.Type == "" || link.Type == "text/html" {
+			return link.Href
+		}
+		if fallback == "" {
+			fallback = link.Href
+		}
+	}
+	return fallback
+}
+
+func enclosureLinks(links []atomLink) []Enclosure {
+	var enclosures []Enclosure
+	for _, link := range links {
+		if link.Rel == "enclosure" && link.Href != "" {
+			enclosures = append(enclosures, Enclosure{
+				URL:    link.Href,
+				Type:   link.Type,
+				Length: parseLength(link.Length),
+			})
+		}
+	}
+	return enclosures
+}
+
+// alternateLink returns the href of the rel="alternate" link, preferring an
+// HTML one. A link without rel counts as alternate per RFC 4287.
+func alternateLink(links []atomLink) string {
+	var fallback string
+	for _, link := range links {
+		if link.Rel != "" && link.Rel != "alternate" {
+			continue
+		}
+		if link.Type == "" || link.Type == "text/html" {
+			return link.Href
+		}
+		if fallback == "" {
+			fallback = link.Href
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,39 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAtomIgnoresExtensionElements(t *testing.T) {
+	feed, err := ParseFeed("application/atom+xml", "https://example.com/feed", strings.NewReader(`<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:media="http://search.yahoo.com/mrss/">
+  <title>Feed</title>
+  <entry>
+    <media:title>Media</media:title>
+    <title>Real</title>
+    <dc:title>DC</dc:title>
+    <id>urn:entry:1</id>
+    <dc:identifier>urn:dc:1</dc:identifier>
+    <updated>2024-01-02T15:04:05Z</updated>
+    <author><name>Ann</name><dc:name>Other</dc:name></author>
+    <summary>Summary</summary>
+  </entry>
+</feed>`), nil)
+	if err != nil {
+		t.Fatalf("ParseFeed: %v", err)
+	}
+	if len(feed.Channel.Item) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Channel.Item))
+	}
+	item := feed.Channel.Item[0]
+	if item.Title != "Real" {
+		t.Errorf("Title = %q, want %q", item.Title, "Real")
+	}
+	if item.Guid != "urn:entry:1" {
+		t.Errorf("Guid = %q, want %q", item.Guid, "urn:entry:1")
+	}
+	if item.Author != "Ann" {
+		t.Errorf("Author = %q, want %q", item.Author, "Ann")
+	}
+}
@@ -0,0 +1,106 @@
+package rss
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// DefaultMaxBodySize caps how many bytes of a feed are read after
+// decompression, so a misconfigured or hostile URL cannot exhaust memory. A
+// fetcher's own MaxBodySize overrides it.
+const DefaultMaxBodySize int64 = 10 << 20
+
+var ErrBodyTooLarge = errors.New("feed body exceeds maximum size")
+
+// readBody returns the decompressed, size-limited body of a response. The
+// Accept-Encoding header is set explicitly in newRequest, which turns off
+// the transport's transparent gzip support, so every encoding we advertise
+// has to be decoded here.
+func readBody(resp *http.Response, maxSize int64) (io.Reader, error) {
+	maxSize = bodyLimit(maxSize)
+	if resp.ContentLength > maxSize {
+		return nil, fmt.Errorf("%w: Content-Length %d > %d bytes", ErrBodyTooLarge, resp.ContentLength, maxSize)
+	}
+
+	body, err := decompress(resp.Header.Get("Content-Encoding"), resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return newLimitedReader(body, maxSize), nil
+}
+
+// bodyLimit returns maxSize, or DefaultMaxBodySize when it is not set.
+func bodyLimit(maxSize int64) int64 {
+	if maxSize <= 0 {
+		return DefaultMaxBodySize
+	}
+	return maxSize
+}
+
+func decompress(contentEncoding string, body io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to open gzip body", err)
+		}
+		return reader, nil
+	case "deflate":
+		// "deflate" is meant to be zlib-wrapped, but some servers send a
+		// raw deflate stream instead.
+		buffered := bufio.NewReader(body)
+		header, err := buffered.Peek(2)
+		if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			reader, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, fmt.Errorf("%w: failed to open deflate body", err)
+			}
+			return reader, nil
+		}
+		return flate.NewReader(buffered), nil
+	case "br":
+		return brotli.NewReader(body), nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding: %q", contentEncoding)
+	}
+}
+
+// limitedReader is io.LimitReader that reports ErrBodyTooLarge instead of a
+// silent EOF when the limit is hit, so truncated feeds are never parsed.
+type limitedReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+func newLimitedReader(r io.Reader, limit int64) *limitedReader {
+	return &limitedReader{r: r, limit: limit, remaining: limit}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, l.limit)
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,132 @@
+package rss
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultTrackingParams are the query parameters CanonicalURL strips from
+// post links unless the config names its own. An entry ending in * matches
+// every parameter with that prefix.
+var DefaultTrackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"mc_cid",
+	"mc_eid",
+	"_hsenc",
+	"_hsmi",
+	"mkt_tok",
+	"igshid",
+	"ref_src",
+}
+
+// CanonicalURL reduces the variants a feed may link the same article by to a
+// single form, so posts can be deduplicated on it:
+//   - http becomes https, and the host is lowercased without a leading
+//     "www.", a trailing dot or a default port;
+//   - trackingParams, in the form of DefaultTrackingParams, are dropped and
+//     the rest are sorted;
+//   - a trailing slash is removed from the path, except for the root;
+//   - the fragment is dropped, unless it is a "#!" route.
+//
+// Links that are not absolute http(s) URLs are returned trimmed but otherwise
+// unchanged.
+func CanonicalURL(link string, trackingParams []string) string {
+	link = strings.TrimSpace(link)
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+	switch u.Scheme = strings.ToLower(u.Scheme); u.Scheme {
+	case "http", "https":
+		u.Scheme = "https"
+	default:
+		return link
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	host = strings.TrimPrefix(host, "www.")
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	u.Host = host
+	u.User = nil
+
+	if u.Path == "" || u.Path == "/" {
+		u.Path, u.RawPath = "/", ""
+	} else if trimmed := strings.TrimRight(u.Path, "/"); trimmed != "" {
+		u.Path, u.RawPath = trimmed, strings.TrimRight(u.RawPath, "/")
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			if isTrackingParam(name, trackingParams) {
+				query.Del(name)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	u.ForceQuery = false
+
+	if !strings.HasPrefix(u.Fragment, "!") {
+		u.Fragment, u.RawFragment = "", ""
+	}
+	return u.String()
+}
+
+func isTrackingParam(name string, trackingParams []string) bool {
+	name = strings.ToLower(name)
+	for _, param := range trackingParams {
+		param = strings.ToLower(param)
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == param {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalLink returns the absolute target of the page's
+// <link rel="canonical">, or "" when it has none. A canonical pointing at the
+// site's front page from an article is a common template mistake and is
+// ignored.
+func canonicalLink(doc *html.Node, base *url.URL) string {
+	var canonical string
+	walkElements(doc, func(n *html.Node) {
+		if n.DataAtom != atom.Link || canonical != "" {
+			return
+		}
+		for _, rel := range strings.Fields(strings.ToLower(attr(n, "rel"))) {
+			if rel != "canonical" {
+				continue
+			}
+			href := strings.TrimSpace(attr(n, "href"))
+			if href == "" {
+				continue
+			}
+			if u, err := base.Parse(href); err == nil && u.Host != "" {
+				canonical = u.String()
+			}
+		}
+	})
+	if canonical == "" {
+		return ""
+	}
+	if u, _ := url.Parse(canonical); strings.Trim(u.Path, "/") == "" && strings.Trim(base.Path, "/") != "" {
+		return ""
+	}
+	return canonical
+}
@@ -0,0 +1,48 @@
+package rss
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		link   string
+		params []string
+		want   string
+	}{
+		// Tracking parameters.
+		{"utm", "https://example.com/a?utm_source=rss&utm_medium=feed", nil, "https://example.com/a"},
+		{"click ids dropped, the rest sorted", "https://example.com/a?b=2&fbclid=x&a=1", nil, "https://example.com/a?a=1&b=2"},
+		{"configured params", "https://example.com/a?ref=rss&utm_source=x", []string{"ref"}, "https://example.com/a?utm_source=x"},
+		{"configured prefix", "https://example.com/a?Share_To=x&id=1", []string{"share_*"}, "https://example.com/a?id=1"},
+
+		// Scheme and host.
+		{"http to https", "http://example.com/a", nil, "https://example.com/a"},
+		{"www", "https://www.Example.COM/a", nil, "https://example.com/a"},
+		{"trailing dot", "https://www.example.com./a", nil, "https://example.com/a"},
+		{"default port", "http://example.com:80/a", nil, "https://example.com/a"},
+		{"other port", "https://example.com:8080/a", nil, "https://example.com:8080/a"},
+
+		// Path and fragment.
+		{"trailing slash", "https://example.com/a/", nil, "https://example.com/a"},
+		{"root", "https://example.com", nil, "https://example.com/"},
+		{"root slash", "https://example.com/", nil, "https://example.com/"},
+		{"fragment", "https://example.com/a#comments", nil, "https://example.com/a"},
+		{"hashbang route", "https://example.com/#!/a", nil, "https://example.com/#!/a"},
+
+		// Links it cannot canonicalize.
+		{"relative", " /posts/1 ", nil, "/posts/1"},
+		{"other scheme", "ftp://example.com/a/", nil, "ftp://example.com/a/"},
+		{"empty", "", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			if params == nil {
+				params = DefaultTrackingParams
+			}
+			if got := CanonicalURL(tt.link, params); got != tt.want {
+				t.Errorf("CanonicalURL(%q) = %q, want %q", tt.link, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// sniffLen is how much of a body is peeked at to detect its format and
+// encoding before streaming the rest.
+const sniffLen = 1024
+
+var xmlEncodingDecl = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// utf8Reader wraps a feed body so it reads as UTF-8. The charset comes from,
+// in order, a byte order mark, the HTTP Content-Type header and the XML
+// declaration, all found in head. A header claiming UTF-8 for bytes that are
+// not UTF-8 is ignored in favour of the declaration, and undeclared non-UTF-8
+// bodies are read as Windows-1252, the most common mislabelled encoding on
+// the web.
+func utf8Reader(contentType string, head []byte, body io.Reader) (io.Reader, error) {
+	valid := validUTF8Prefix(head)
+	enc := bomEncoding(head)
+
+	if enc == nil {
+		if label := contentTypeCharset(contentType); label != "" {
+			if e, name := charset.Lookup(label); e != nil && (name != "utf-8" || valid) {
+				enc = e
+			}
+		}
+	}
+
+	if enc == nil {
+		if match := xmlEncodingDecl.FindSubmatch(head); match != nil {
+			e, name := charset.Lookup(string(match[1]))
+			if e == nil {
+				return nil, fmt.Errorf("unsupported feed encoding: %q", match[1])
+			}
+			if name != "utf-8" || valid {
+				enc = e
+			}
+		}
+	}
+
+	if enc == nil {
+		if valid {
+			enc = unicode.UTF8BOM
+		} else {
+			enc = charmap.Windows1252
+		}
+	}
+
+	return enc.NewDecoder().Reader(body), nil
+}
+
+// validUTF8Prefix is utf8.Valid, tolerating a rune cut off at the end of a
+// peeked prefix.
+func validUTF8Prefix(head []byte) bool {
+	if len(head) < sniffLen {
+		return utf8.Valid(head)
+	}
+	for i := 0; i < utf8.UTFMax; i++ {
+		if utf8.Valid(head[:len(head)-i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func bomEncoding(data []byte) encoding.Encoding {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xef\xbb\xbf")):
+		return unicode.UTF8BOM
+	case bytes.HasPrefix(data, []byte("\xfe\xff")):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	case bytes.HasPrefix(data, []byte("\xff\xfe")):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	}
+	return nil
+}
+
+func contentTypeCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params["charset"])
+}
+
+// newXMLDecoder returns a decoder for a body already wrapped by utf8Reader,
+// so the encoding named in its XML declaration no longer applies.
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder
+}
+
+// peek returns up to sniffLen bytes from the start of r without consuming them.
+func peek(r *bufio.Reader) ([]byte, error) {
+	head, err := r.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	return head, nil
+}
@@ -0,0 +1,322 @@
+package rss
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DateParser turns the publication dates found in feed items into times.
+// Items without a date fall back to FetchedAt. Items whose date cannot be
+// parsed also fall back, and are recorded in Failures so the caller can
+// report them instead of aborting the fetch.
+type DateParser struct {
+	FetchedAt time.Time
+	Failures  []DateFailure
+}
+
+type DateFailure struct {
+	Title string
+	Link  string
+	Raw   string
+	Err   error
+}
+
+func (p *DateParser) Parse(item RSSItem) time.Time {
+	if strings.TrimSpace(item.PubDate) == "" {
+		return p.FetchedAt
+	}
+
+	t, err := ParseDate(item.PubDate)
+	if err != nil {
+		p.Failures = append(p.Failures, DateFailure{
+			Title: item.Title,
+			Link:  item.Link,
+			Raw:   item.PubDate,
+			Err:   err,
+		})
+		return p.FetchedAt
+	}
+	return t
+}
+
+// ParseDate parses the date formats seen in real-world feeds: RFC 822/1123
+// with numeric offsets or zone names, RFC 3339 and the other W3C-DTF
+// profiles, common SQL and slash layouts, Go's time.Time.String output,
+// Unix timestamps, and the month and weekday names of the most common
+// non-English locales.
+func ParseDate(value string) (time.Time, error) {
+	normalized := normalizeDate(value)
+	if normalized == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, normalized); err == nil {
+			return t, nil
+		}
+	}
+
+	if seconds, err := strconv.ParseInt(normalized, 10, 64); err == nil && seconds > 0 {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date format: %q", value)
+}
+
+// dateLayouts are tried in order against a normalized date, which has its
+// weekday removed, month names reduced to English abbreviations and zone
+// names replaced by numeric offsets.
+var dateLayouts = []string{
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006 3:04:05 PM",
+	"2 Jan 2006 3:04 PM",
+	"2 Jan 2006",
+	"2-Jan-2006 15:04:05 -0700",
+	"2-Jan-06 15:04:05 -0700",
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2 2006 15:04:05",
+	"Jan 2 2006 15:04 -0700",
+	"Jan 2 2006 15:04",
+	"Jan 2 2006 3:04:05 PM",
+	"Jan 2 2006 3:04 PM",
+	"Jan 2 2006",
+	"Jan 2 15:04:05 -0700 2006",
+	"Jan 2 15:04:05 2006",
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02 3:04:05 PM",
+	"2006-01-02 3:04 PM",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"2006.01.02 15:04:05",
+	"2006.01.02",
+	"2.1.2006 15:04:05",
+	"2.1.2006",
+	"2006-01",
+	"2006",
+}
+
+func normalizeDate(value string) string {
+	value = strings.TrimSpace(value)
+	// Drop trailing comments such as "+0000 (UTC)".
+	if i := strings.Index(value, "("); i > 0 && strings.HasSuffix(value, ")") {
+		value = strings.TrimSpace(value[:i])
+	}
+
+	var tokens []string
+	for i, token := range strings.Fields(value) {
+		// "Tue," or "mar.," is a weekday; drop it before it can be
+		// mistaken for a month abbreviation.
+		if i == 0 && strings.HasSuffix(token, ",") && !isNumeric(strings.TrimRight(token, ",")) {
+			continue
+		}
+		token = strings.TrimRight(token, ",")
+		lower := strings.ToLower(strings.TrimRight(token, "."))
+
+		if month, ok := monthNames[lower]; ok {
+			tokens = append(tokens, month)
+			continue
+		}
+		if fillerWords[lower] {
+			continue
+		}
+		if isNumeric(strings.TrimRight(token, ".")) {
+			token = strings.TrimRight(token, ".")
+		}
+		tokens = append(tokens, token)
+	}
+
+	// A leading word that is not a month is a weekday, in whatever language.
+	if len(tokens) > 1 && isAlpha(tokens[0]) && !isMonthAbbr(tokens[0]) {
+		tokens = tokens[1:]
+	}
+
+	// Go's time.String appends the monotonic clock reading, "m=+0.001", and
+	// the zone name after the numeric offset, "+0000 UTC". The offset is
+	// enough.
+	if n := len(tokens); n > 1 && strings.HasPrefix(tokens[n-1], "m=") {
+		tokens = tokens[:n-1]
+	}
+	if n := len(tokens); n > 2 && isAlpha(tokens[n-1]) && isNumericOffset(tokens[n-2]) {
+		tokens = tokens[:n-1]
+	}
+
+	if n := len(tokens); n > 1 {
+		last := tokens[n-1]
+		if upper := strings.ToUpper(last); upper == "AM" || upper == "PM" {
+			tokens[n-1] = upper
+		} else if offset, ok := zoneOffsets[upper]; ok {
+			tokens[n-1] = offset
+		} else if isAlpha(last) {
+			// Unknown zone names are treated as UTC rather than rejected.
+			tokens[n-1] = "+0000"
+		} else if strings.HasPrefix(upper, "GMT") || strings.HasPrefix(upper, "UTC") {
+			tokens[n-1] = numericOffset(last[3:])
+		}
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+// numericOffset turns the "+2", "+5:30" or "-0530" suffix of "GMT+2" into
+// "+0200", "+0530" or "-0530".
+func numericOffset(suffix string) string {
+	if suffix == "" {
+		return "+0000"
+	}
+	sign, rest := suffix[:1], suffix[1:]
+	hours, minutes, ok := strings.Cut(rest, ":")
+	if !ok && len(rest) > 2 {
+		hours, minutes = rest[:len(rest)-2], rest[len(rest)-2:]
+	}
+	return sign + zeroPad(hours) + zeroPad(minutes)
+}
+
+// zeroPad left-pads a one-digit offset part to two digits.
+func zeroPad(s string) string {
+	return strings.Repeat("0", max(0, 2-len(s))) + s
+}
+
+// isNumericOffset reports whether s is a zone offset such as "+0000" or
+// "-05:00".
+func isNumericOffset(s string) bool {
+	return len(s) > 1 && (s[0] == '+' || s[0] == '-') && isNumeric(strings.ReplaceAll(s[1:], ":", ""))
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func isMonthAbbr(s string) bool {
+	for _, month := range monthNames {
+		if month == s {
+			return true
+		}
+	}
+	return false
+}
+
+// fillerWords appear between date parts in Spanish and Portuguese dates,
+// e.g. "2 de enero de 2024".
+var fillerWords = map[string]bool{
+	"de":  true,
+	"del": true,
+	"at":  true,
+	"um":  true,
+	"à":   true,
+}
+
+var zoneOffsets = map[string]string{
+	"Z":    "+0000",
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"WET":  "+0000",
+	"WEST": "+0100",
+	"BST":  "+0100",
+	"CET":  "+0100",
+	"MEZ":  "+0100",
+	"CEST": "+0200",
+	"MESZ": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"MSK":  "+0300",
+	"IST":  "+0530",
+	"SGT":  "+0800",
+	"HKT":  "+0800",
+	"AWST": "+0800",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"ACST": "+0930",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+	"AST":  "-0400",
+	"EDT":  "-0400",
+	"EST":  "-0500",
+	"CDT":  "-0500",
+	"CST":  "-0600",
+	"MDT":  "-0600",
+	"MST":  "-0700",
+	"PDT":  "-0700",
+	"PST":  "-0800",
+	"AKDT": "-0800",
+	"AKST": "-0900",
+	"HST":  "-1000",
+}
+
+// monthNames maps full and abbreviated month names in English, French,
+// German, Spanish, Italian, Portuguese and Dutch to the English abbreviation
+// understood by time.Parse.
+var monthNames = map[string]string{
+	"jan": "Jan", "january": "Jan", "janvier": "Jan", "janv": "Jan", "januar": "Jan", "jänner": "Jan",
+	"enero": "Jan", "ene": "Jan", "gennaio": "Jan", "gen": "Jan", "janeiro": "Jan", "januari": "Jan",
+
+	"feb": "Feb", "february": "Feb", "février": "Feb", "fevrier": "Feb", "févr": "Feb", "fevr": "Feb",
+	"februar": "Feb", "febrero": "Feb", "febbraio": "Feb", "fevereiro": "Feb", "fev": "Feb", "februari": "Feb",
+
+	"mar": "Mar", "march": "Mar", "mars": "Mar", "märz": "Mar", "mär": "Mar", "mrz": "Mar",
+	"marzo": "Mar", "março": "Mar", "marco": "Mar", "maart": "Mar", "mrt": "Mar",
+
+	"apr": "Apr", "april": "Apr", "avril": "Apr", "avr": "Apr", "abril": "Apr", "abr": "Apr", "aprile": "Apr",
+
+	"may": "May", "mai": "May", "mayo": "May", "maggio": "May", "mag": "May", "maio": "May", "mei": "May",
+
+	"jun": "Jun", "june": "Jun", "juin": "Jun", "juni": "Jun", "junio": "Jun", "giugno": "Jun",
+	"giu": "Jun", "junho": "Jun",
+
+	"jul": "Jul", "july": "Jul", "juillet": "Jul", "juil": "Jul", "juli": "Jul", "julio": "Jul",
+	"luglio": "Jul", "lug": "Jul", "julho": "Jul",
+
+	"aug": "Aug", "august": "Aug", "août": "Aug", "aout": "Aug", "agosto": "Aug", "ago": "Aug", "augustus": "Aug",
+
+	"sep": "Sep", "sept": "Sep", "september": "Sep", "septembre": "Sep", "septiembre": "Sep",
+	"setiembre": "Sep", "settembre": "Sep", "set": "Sep", "setembro": "Sep",
+
+	"oct": "Oct", "october": "Oct", "octobre": "Oct", "oktober": "Oct", "okt": "Oct", "octubre": "Oct",
+	"ottobre": "Oct", "ott": "Oct", "outubro": "Oct", "out": "Oct",
+
+	"nov": "Nov", "november": "Nov", "novembre": "Nov", "noviembre": "Nov", "novembro": "Nov",
+
+	"dec": "Dec", "december": "Dec", "décembre": "Dec", "decembre": "Dec", "déc": "Dec", "dezember": "Dec",
+	"dez": "Dec", "diciembre": "Dec", "dic": "Dec", "dicembre": "Dec", "dezembro": "Dec",
+}
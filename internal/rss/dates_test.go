@@ -0,0 +1,105 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	}
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		// RFC 822 and 1123.
+		{"rfc1123 gmt", "Tue, 02 Jan 2024 15:04:05 GMT", utc(2024, 1, 2, 15, 4, 5)},
+		{"rfc1123z", "Tue, 02 Jan 2024 15:04:05 +0100", utc(2024, 1, 2, 14, 4, 5)},
+		{"rfc822 two-digit year", "02 Jan 24 15:04 -0500", utc(2024, 1, 2, 20, 4, 0)},
+		{"offset comment", "Tue, 02 Jan 2024 15:04:05 +0000 (UTC)", utc(2024, 1, 2, 15, 4, 5)},
+		{"no weekday", "2 Jan 2024 15:04:05 +0000", utc(2024, 1, 2, 15, 4, 5)},
+		{"full month name", "Tuesday, 2 January 2024 15:04:05 GMT", utc(2024, 1, 2, 15, 4, 5)},
+
+		// Zone names and GMT offsets.
+		{"zone EST", "Tue, 02 Jan 2024 10:04:05 EST", utc(2024, 1, 2, 15, 4, 5)},
+		{"zone PDT", "Tue, 02 Jul 2024 08:04:05 PDT", utc(2024, 7, 2, 15, 4, 5)},
+		{"zone CEST", "Tue, 02 Jul 2024 17:04:05 CEST", utc(2024, 7, 2, 15, 4, 5)},
+		{"zone IST", "Tue, 02 Jan 2024 20:34:05 IST", utc(2024, 1, 2, 15, 4, 5)},
+		{"unknown zone as utc", "Tue, 02 Jan 2024 15:04:05 XYZT", utc(2024, 1, 2, 15, 4, 5)},
+		{"gmt+2", "Tue, 02 Jan 2024 17:04:05 GMT+2", utc(2024, 1, 2, 15, 4, 5)},
+		{"gmt-05:00", "Tue, 02 Jan 2024 10:04:05 GMT-05:00", utc(2024, 1, 2, 15, 4, 5)},
+		{"gmt+5:30", "Tue, 02 Jan 2024 20:34:05 GMT+5:30", utc(2024, 1, 2, 15, 4, 5)},
+		{"utc+0530", "Tue, 02 Jan 2024 20:34:05 UTC+0530", utc(2024, 1, 2, 15, 4, 5)},
+		{"bare gmt", "Tue, 02 Jan 2024 15:04:05 GMT", utc(2024, 1, 2, 15, 4, 5)},
+
+		// RFC 3339 and the W3C-DTF profiles.
+		{"rfc3339", "2024-01-02T15:04:05Z", utc(2024, 1, 2, 15, 4, 5)},
+		{"rfc3339 offset", "2024-01-02T16:04:05+01:00", utc(2024, 1, 2, 15, 4, 5)},
+		{"rfc3339 fraction", "2024-01-02T15:04:05.123Z", time.Date(2024, 1, 2, 15, 4, 5, 123e6, time.UTC)},
+		{"w3c minutes", "2024-01-02T16:04+01:00", utc(2024, 1, 2, 15, 4, 0)},
+		{"w3c day", "2024-01-02", utc(2024, 1, 2, 0, 0, 0)},
+		{"w3c month", "2024-01", utc(2024, 1, 1, 0, 0, 0)},
+		{"w3c year", "2024", utc(2024, 1, 1, 0, 0, 0)},
+		{"offset without colon", "2024-01-02T16:04:05+0100", utc(2024, 1, 2, 15, 4, 5)},
+
+		// SQL, slash and dotted layouts, and Unix timestamps.
+		{"sql", "2024-01-02 15:04:05", utc(2024, 1, 2, 15, 4, 5)},
+		{"sql offset", "2024-01-02 16:04:05 +01:00", utc(2024, 1, 2, 15, 4, 5)},
+		{"slash", "2024/01/02 15:04:05", utc(2024, 1, 2, 15, 4, 5)},
+		{"dotted", "2.1.2024", utc(2024, 1, 2, 0, 0, 0)},
+		{"unix", "1704207845", utc(2024, 1, 2, 15, 4, 5)},
+
+		// Go's time.Time.String.
+		{"go string utc", "2024-01-02 15:04:05 +0000 UTC", utc(2024, 1, 2, 15, 4, 5)},
+		{"go string zone", "2024-01-02 10:04:05.5 -0500 EST", time.Date(2024, 1, 2, 15, 4, 5, 5e8, time.UTC)},
+		{"go string monotonic", "2024-01-02 15:04:05.000000001 +0000 UTC m=+0.000123", time.Date(2024, 1, 2, 15, 4, 5, 1, time.UTC)},
+
+		// Locales.
+		{"french", "mar., 2 janv. 2024 15:04:05 +0000", utc(2024, 1, 2, 15, 4, 5)},
+		{"french full", "mardi 2 février 2024 15:04", utc(2024, 2, 2, 15, 4, 0)},
+		{"german", "Di, 2 Mär 2024 15:04:05 +0000", utc(2024, 3, 2, 15, 4, 5)},
+		{"german full", "Dienstag, 2. Dezember 2024 15:04:05 +0000", utc(2024, 12, 2, 15, 4, 5)},
+		{"spanish", "martes, 2 de enero de 2024 15:04:05 +0000", utc(2024, 1, 2, 15, 4, 5)},
+		{"italian", "2 maggio 2024 15:04", utc(2024, 5, 2, 15, 4, 0)},
+		{"portuguese", "terça-feira, 2 de março de 2024", utc(2024, 3, 2, 0, 0, 0)},
+		{"dutch", "di 2 mei 2024 15:04:05 +0000", utc(2024, 5, 2, 15, 4, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDate(tt.value)
+			if err != nil {
+				t.Fatalf("ParseDate(%q): %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDate(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, value := range []string{"", "   ", "yesterday", "32 Foo 2024"} {
+		if got, err := ParseDate(value); err == nil {
+			t.Errorf("ParseDate(%q) = %v, want an error", value, got)
+		}
+	}
+}
+
+func TestNumericOffset(t *testing.T) {
+	tests := map[string]string{
+		"":       "+0000",
+		"+2":     "+0200",
+		"-11":    "-1100",
+		"+5:30":  "+0530",
+		"-05:00": "-0500",
+		"+530":   "+0530",
+		"+0545":  "+0545",
+	}
+	for suffix, want := range tests {
+		if got := numericOffset(suffix); got != want {
+			t.Errorf("numericOffset(%q) = %q, want %q", suffix, got, want)
+		}
+	}
+}
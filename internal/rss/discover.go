@@ -0,0 +1,160 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// FeedCandidate is a feed found while looking at a website.
+type FeedCandidate struct {
+	URL   string
+	Title string
+	Type  string
+}
+
+var feedMediaTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/rdf+xml":   true,
+}
+
+// commonFeedPaths are probed when a page advertises no feeds itself.
+var commonFeedPaths = []string{
+	"/feed",
+	"/feed/",
+	"/rss",
+	"/rss.xml",
+	"/feed.xml",
+	"/atom.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+// DiscoverFeeds returns the feeds behind a URL. A URL that already is a feed
+// is returned as the only candidate. For an HTML page the
+// <link rel="alternate"> feeds it advertises are returned, and when there are
+// none the common feed paths of the site are probed.
+func DiscoverFeeds(ctx context.Context, fetcher Fetcher, pageURL string) ([]FeedCandidate, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%w: invalid URL", err)
+	}
+
+	contentType, data, err := fetcher.FetchPage(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if feed, err := parseFeed(contentType, data); err == nil {
+		return []FeedCandidate{{URL: pageURL, Title: feed.Channel.Title}}, nil
+	}
+
+	candidates, err := alternateFeedLinks(u, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	for _, path := range commonFeedPaths {
+		probeURL := u.ResolveReference(&url.URL{Path: path}).String()
+		contentType, data, err := fetcher.FetchPage(ctx, probeURL)
+		if err != nil {
+			continue
+		}
+		feed, err := parseFeed(contentType, data)
+		if err != nil {
+			continue
+		}
+		// The first path that serves a feed wins; the others are usually
+		// aliases of the same document.
+		candidates = append(candidates, FeedCandidate{URL: probeURL, Title: feed.Channel.Title})
+		break
+	}
+	return candidates, nil
+}
+
+// pageFromResponse reads a page response, live or replayed from a fixture,
+// returning its content type and decompressed body.
+func pageFromResponse(resp *http.Response, maxSize int64) (string, []byte, error) {
+	if resp.StatusCode > 299 {
+		return "", nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	body, err := readBody(resp, maxSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed reading response: %w", err)
+	}
+	return resp.Header.Get("Content-Type"), data, nil
+}
+
+// alternateFeedLinks collects the feed links advertised in an HTML page's
+// head, resolved against the page URL or its <base href>.
+func alternateFeedLinks(pageURL *url.URL, data []byte) ([]FeedCandidate, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse html", err)
+	}
+
+	base := pageURL
+	var candidates []FeedCandidate
+	seen := map[string]bool{}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "base" {
+			if href, err := url.Parse(attr(n, "href")); err == nil && attr(n, "href") != "" {
+				base = pageURL.ResolveReference(href)
+			}
+		}
+		if n.Type == html.ElementNode && n.Data == "link" && hasToken(attr(n, "rel"), "alternate") {
+			mediaType := strings.ToLower(strings.TrimSpace(attr(n, "type")))
+			href, err := url.Parse(strings.TrimSpace(attr(n, "href")))
+			if feedMediaTypes[mediaType] && err == nil && href.String() != "" {
+				feedURL := base.ResolveReference(href).String()
+				if !seen[feedURL] {
+					seen[feedURL] = true
+					candidates = append(candidates, FeedCandidate{URL: feedURL, Title: attr(n, "title"), Type: mediaType})
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return candidates, nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,391 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// wordsPerMinute is the reading speed behind Article.ReadingMinutes.
+const wordsPerMinute = 200
+
+var ErrNoArticle = errors.New("no article content found")
+
+// Article is the main content of a web page, as found by ExtractArticle.
+type Article struct {
+	// HTML keeps only structural and inline formatting tags, with links and
+	// images made absolute.
+	HTML      string
+	Text      string
+	WordCount int
+	// Canonical is the page's rel=canonical URL, or "" when it names none.
+	Canonical string
+}
+
+// ReadingMinutes estimates how long the article takes to read, rounded up
+// to at least one minute.
+func (a *Article) ReadingMinutes() int {
+	return max(1, int(math.Ceil(float64(a.WordCount)/wordsPerMinute)))
+}
+
+// ExtractArticle downloads a page and extracts its main content, in the
+// spirit of Readability: boilerplate elements are dropped and the element
+// holding the most paragraph text, weighted by its class and id and by how
+// little of it is links, wins.
+func ExtractArticle(ctx context.Context, fetcher Fetcher, pageURL string) (*Article, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("%w: invalid page URL", err)
+	}
+
+	contentType, data, err := fetcher.FetchPage(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := charset.NewReader(bytes.NewReader(data), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed decoding page", err)
+	}
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed parsing page", err)
+	}
+	base = documentBase(doc, base)
+	canonical := canonicalLink(doc, base)
+	article, err := extractArticle(doc, base)
+	if err != nil {
+		return nil, err
+	}
+	article.Canonical = canonical
+	return article, nil
+}
+
+var (
+	positiveHint = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text`)
+	negativeHint = regexp.MustCompile(`(?i)(^|[\s_-])ads?([\s_-]|$)|advert|banner|breadcrumb|comment|cookie|footer|menu|meta|modal|nav|newsletter|popup|promo|related|share|sidebar|social|sponsor|subscribe|widget`)
+)
+
+// minArticleText is the least text, in bytes, an extraction must yield.
+const minArticleText = 200
+
+func extractArticle(doc *html.Node, base *url.URL) (*Article, error) {
+	removeBoilerplate(doc)
+
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = tagWeight(n) + classWeight(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	walkElements(doc, func(n *html.Node) {
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		default:
+			return
+		}
+		text := strings.TrimSpace(nodeText(n))
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	var bestScore float64
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(n))
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return nil, ErrNoArticle
+	}
+
+	text := plainText(best)
+	if len(text) < minArticleText {
+		return nil, ErrNoArticle
+	}
+
+	var b strings.Builder
+	for child := best.FirstChild; child != nil; child = child.NextSibling {
+		renderClean(&b, child, base)
+	}
+	return &Article{
+		HTML:      strings.TrimSpace(b.String()),
+		Text:      text,
+		WordCount: len(strings.Fields(text)),
+	}, nil
+}
+
+// documentBase honours a <base href> in the page.
+func documentBase(doc *html.Node, pageURL *url.URL) *url.URL {
+	base := pageURL
+	walkElements(doc, func(n *html.Node) {
+		if n.DataAtom == atom.Base && base == pageURL {
+			if href := attr(n, "href"); href != "" {
+				if u, err := pageURL.Parse(href); err == nil {
+					base = u
+				}
+			}
+		}
+	})
+	return base
+}
+
+// removeBoilerplate drops elements that are never article content: scripts,
+// navigation, forms, and containers whose class or id says they are
+// comments, sidebars, ads and the like.
+func removeBoilerplate(doc *html.Node) {
+	var remove []*html.Node
+	walkElements(doc, func(n *html.Node) {
+		switch n.DataAtom {
+		case atom.Script, atom.Style, atom.Noscript, atom.Iframe, atom.Object, atom.Embed,
+			atom.Form, atom.Button, atom.Input, atom.Select, atom.Textarea, atom.Svg,
+			atom.Nav, atom.Header, atom.Footer, atom.Aside:
+			remove = append(remove, n)
+			return
+		case atom.Html, atom.Body, atom.Article, atom.Main:
+			return
+		}
+		hints := attr(n, "class") + " " + attr(n, "id")
+		if negativeHint.MatchString(hints) && !positiveHint.MatchString(hints) {
+			remove = append(remove, n)
+		}
+	})
+	for _, n := range remove {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+func tagWeight(n *html.Node) float64 {
+	switch n.DataAtom {
+	case atom.Article, atom.Main:
+		return 10
+	case atom.Div, atom.Section:
+		return 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		return 3
+	case atom.Ol, atom.Ul, atom.Dl, atom.Form:
+		return -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		return -5
+	}
+	return 0
+}
+
+func classWeight(n *html.Node) float64 {
+	var weight float64
+	for _, hint := range []string{attr(n, "class"), attr(n, "id")} {
+		if hint == "" {
+			continue
+		}
+		if negativeHint.MatchString(hint) {
+			weight -= 25
+		}
+		if positiveHint.MatchString(hint) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity is the share of an element's text that sits inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(nodeText(n))
+	if total == 0 {
+		return 0
+	}
+	var linked int
+	walkElements(n, func(child *html.Node) {
+		if child.DataAtom == atom.A {
+			linked += len(nodeText(child))
+		}
+	})
+	return math.Min(float64(linked)/float64(total), 1)
+}
+
+// keptTags are the elements renderClean writes out. Any other element is
+// replaced by its children.
+var keptTags = map[atom.Atom]bool{
+	atom.P: true, atom.Br: true, atom.Hr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Code: true,
+	atom.Em: true, atom.Strong: true, atom.B: true, atom.I: true, atom.U: true, atom.S: true,
+	atom.Sub: true, atom.Sup: true, atom.Small: true, atom.Mark: true, atom.Q: true, atom.Cite: true,
+	atom.A: true, atom.Img: true, atom.Figure: true, atom.Figcaption: true,
+	atom.Table: true, atom.Thead: true, atom.Tbody: true, atom.Tr: true, atom.Th: true, atom.Td: true,
+}
+
+var voidTags = map[atom.Atom]bool{atom.Br: true, atom.Hr: true, atom.Img: true}
+
+// renderClean writes n as HTML holding only keptTags, with only href, src,
+// alt and title attributes and every link made absolute against base.
+func renderClean(b *strings.Builder, n *html.Node, base *url.URL) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Iframe, atom.Object, atom.Embed,
+		atom.Svg, atom.Math, atom.Head, atom.Title:
+		return
+	}
+
+	if !keptTags[n.DataAtom] {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			renderClean(b, child, base)
+		}
+		return
+	}
+
+	var attrs []html.Attribute
+	switch n.DataAtom {
+	case atom.A:
+		if href := absoluteURL(base, attr(n, "href"), "http", "https", "mailto"); href != "" {
+			attrs = append(attrs, html.Attribute{Key: "href", Val: href})
+		}
+	case atom.Img:
+		src := absoluteURL(base, attr(n, "src"), "http", "https")
+		// Images without a usable source and 1x1 tracking pixels are dropped.
+		if src == "" || attr(n, "width") == "1" || attr(n, "height") == "1" {
+			return
+		}
+		attrs = append(attrs, html.Attribute{Key: "src", Val: src})
+		if alt := attr(n, "alt"); alt != "" {
+			attrs = append(attrs, html.Attribute{Key: "alt", Val: alt})
+		}
+	}
+	if title := attr(n, "title"); title != "" {
+		attrs = append(attrs, html.Attribute{Key: "title", Val: title})
+	}
+
+	b.WriteString("<" + n.Data)
+	for _, a := range attrs {
+		b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	b.WriteString(">")
+	if voidTags[n.DataAtom] {
+		return
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		renderClean(b, child, base)
+	}
+	b.WriteString("</" + n.Data + ">")
+}
+
+// absoluteURL resolves ref against base and returns it when its scheme is
+// one of schemes. Without a base, relative references are kept as they are.
+func absoluteURL(base *url.URL, ref string, schemes ...string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	if base == nil {
+		base = &url.URL{}
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if u.Scheme == "" && !base.IsAbs() {
+		return u.String()
+	}
+	for _, scheme := range schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return u.String()
+		}
+	}
+	return ""
+}
+
+var blockTags = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Figure: true, atom.Figcaption: true,
+	atom.Table: true, atom.Tr: true, atom.Br: true, atom.Hr: true,
+}
+
+// plainText renders an element as text, with block elements as paragraphs
+// separated by blank lines.
+func plainText(n *html.Node) string {
+	var b strings.Builder
+	var write func(n *html.Node)
+	write = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.ElementNode:
+			block := blockTags[n.DataAtom]
+			if block {
+				b.WriteString("\n\n")
+			}
+			for child := n.FirstChild; child != nil; child = child.NextSibling {
+				write(child)
+			}
+			if block {
+				b.WriteString("\n\n")
+			}
+		}
+	}
+	write(n)
+
+	var paragraphs []string
+	for _, paragraph := range strings.Split(b.String(), "\n\n") {
+		if paragraph = strings.Join(strings.Fields(paragraph), " "); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// nodeText is the raw text content of a node.
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(nodeText(child))
+	}
+	return b.String()
+}
+
+func walkElements(n *html.Node, visit func(n *html.Node)) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode {
+			visit(child)
+		}
+		walkElements(child, visit)
+	}
+}
@@ -0,0 +1,114 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileFetcher reads feeds from file:// URLs, so locally generated feeds can
+// be aggregated. The file's modification time stands in for Last-Modified.
+type FileFetcher struct {
+	// MaxBodySize caps the size of a feed file; 0 means DefaultMaxBodySize.
+	MaxBodySize int64
+}
+
+func (f FileFetcher) Fetch(ctx context.Context, feedURL string, validators Validators, visit ItemFunc) (*FetchResult, error) {
+	path, info, err := f.stat(feedURL)
+	if err != nil {
+		return nil, err
+	}
+
+	modified := Validators{LastModified: info.ModTime().UTC().Format(http.TimeFormat)}
+	// Only the media type: the charset the system's MIME table adds is not
+	// something the feed declared.
+	contentType, _, _ := strings.Cut(mime.TypeByExtension(filepath.Ext(path)), ";")
+	header := http.Header{}
+	header.Set("Last-Modified", modified.LastModified)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if validators.LastModified == modified.LastModified {
+		return &FetchResult{Validators: modified, NotModified: true, Header: header}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading feed file: %w", err)
+	}
+
+	defer file.Close()
+	result := &FetchResult{Validators: modified, Header: header}
+	var body io.Reader = newLimitedReader(file, bodyLimit(f.MaxBodySize))
+	if visit == nil {
+		if result.Body, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(result.Body)
+	}
+	sink := &itemSink{feedURL: &url.URL{Scheme: "file", Path: filepath.ToSlash(path)}, clean: true, visit: visit}
+	rssFeed, err := decodeFeed(contentType, body, sink)
+	if err != nil {
+		return nil, &ParseError{Result: result, Err: err}
+	}
+
+	result.Feed = rssFeed
+	return result, nil
+}
+
+// FetchPage reads a local page, typed by its file extension.
+func (f FileFetcher) FetchPage(ctx context.Context, pageURL string) (string, []byte, error) {
+	path, _, err := f.stat(pageURL)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	return mime.TypeByExtension(filepath.Ext(path)), data, nil
+}
+
+// stat returns the path of a file URL and its file info, refusing files over
+// the size limit.
+func (f FileFetcher) stat(fileURL string) (string, os.FileInfo, error) {
+	path, err := filePath(fileURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed reading feed file: %w", err)
+	}
+	if maxSize := bodyLimit(f.MaxBodySize); info.Size() > maxSize {
+		return "", nil, fmt.Errorf("%w: file size %d > %d bytes", ErrBodyTooLarge, info.Size(), maxSize)
+	}
+	return path, info, nil
+}
+
+// filePath returns the local path of a file:// URL. Both file:///abs/path
+// and the relative file:path form are accepted.
+func filePath(feedURL string) (string, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil || u.Scheme != "file" {
+		return "", fmt.Errorf("%w: invalid file URL: %s", err, feedURL)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("file URL must be local: %s", feedURL)
+	}
+	if u.Opaque != "" {
+		return filepath.FromSlash(u.Opaque), nil
+	}
+	if u.Path == "" {
+		return "", fmt.Errorf("file URL has no path: %s", feedURL)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
@@ -0,0 +1,122 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FixtureFetcher replays HTTP responses recorded in Dir, one file per URL,
+// so aggregation, feed discovery and full-text extraction can run offline and
+// deterministically. When Record is set, a URL with no fixture yet is fetched
+// with it and its response saved first; otherwise a missing fixture is an
+// error.
+type FixtureFetcher struct {
+	Dir    string
+	Record *HTTPFetcher
+	// MaxBodySize caps how many bytes of a replayed body are read; 0 means
+	// DefaultMaxBodySize.
+	MaxBodySize int64
+}
+
+func (f *FixtureFetcher) Fetch(ctx context.Context, feedURL string, validators Validators, visit ItemFunc) (*FetchResult, error) {
+	req, err := newRequest(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.replay(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	return resultFromResponse(resp, validators, nil, f.MaxBodySize, visit)
+}
+
+func (f *FixtureFetcher) FetchPage(ctx context.Context, pageURL string) (string, []byte, error) {
+	req, err := newPageRequest(ctx, pageURL)
+	if err != nil {
+		return "", nil, err
+	}
+	resp, err := f.replay(req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	defer resp.Body.Close()
+	return pageFromResponse(resp, f.MaxBodySize)
+}
+
+// FixturePath is the file a URL's response is recorded in: a raw HTTP/1.1
+// response as written by httputil.DumpResponse.
+func (f *FixtureFetcher) FixturePath(feedURL string) string {
+	sum := sha256.Sum256([]byte(feedURL))
+	name := "feed"
+	if u, err := url.Parse(feedURL); err == nil && u.Hostname() != "" {
+		name = strings.ReplaceAll(u.Hostname(), ".", "_")
+	}
+	return filepath.Join(f.Dir, name+"-"+hex.EncodeToString(sum[:8])+".http")
+}
+
+// replay returns the recorded response to req, recording it first when
+// needed.
+func (f *FixtureFetcher) replay(req *http.Request) (*http.Response, error) {
+	rawURL := req.URL.String()
+	path := f.FixturePath(rawURL)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && f.Record != nil {
+		if err := f.record(req, path); err != nil {
+			return nil, err
+		}
+	}
+
+	dump, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading fixture for %s: %w", rawURL, err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid fixture %s", err, path)
+	}
+	return resp, nil
+}
+
+func (f *FixtureFetcher) record(req *http.Request, path string) error {
+	rawURL := req.URL.String()
+	if err := f.Record.wait(req.Context(), rawURL); err != nil {
+		return err
+	}
+	resp, _, err := f.Record.get(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	maxSize := bodyLimit(f.Record.MaxBodySize)
+	if resp.ContentLength > maxSize {
+		return fmt.Errorf("%w: Content-Length %d > %d bytes", ErrBodyTooLarge, resp.ContentLength, maxSize)
+	}
+	resp.Body = io.NopCloser(io.LimitReader(resp.Body, maxSize))
+
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return fmt.Errorf("failed recording fixture for %s: %w", rawURL, err)
+	}
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed creating fixture dir: %w", err)
+	}
+	if err := os.WriteFile(path, dump, 0o644); err != nil {
+		return fmt.Errorf("failed writing fixture: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package rss
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixtureFetcherReplaysSite(t *testing.T) {
+	fetcher := &FixtureFetcher{Dir: filepath.Join("testdata", "fixtures")}
+	ctx := context.Background()
+
+	candidates, err := DiscoverFeeds(ctx, fetcher, "https://blog.example.com/")
+	if err != nil {
+		t.Fatalf("DiscoverFeeds: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].URL != "https://blog.example.com/feed.xml" {
+		t.Fatalf("DiscoverFeeds = %+v, want the advertised feed.xml", candidates)
+	}
+
+	result, err := fetcher.Fetch(ctx, candidates[0].URL, Validators{}, nil)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if want := (Validators{ETag: `"v1"`, LastModified: "Mon, 05 Feb 2024 10:00:00 GMT"}); result.Validators != want {
+		t.Errorf("Validators = %+v, want %+v", result.Validators, want)
+	}
+	items := result.Feed.Channel.Item
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	if want := "https://blog.example.com/posts/hello?utm_source=rss"; items[0].Link != want {
+		t.Fatalf("item link = %q, want %q", items[0].Link, want)
+	}
+
+	article, err := ExtractArticle(ctx, fetcher, items[0].Link)
+	if err != nil {
+		t.Fatalf("ExtractArticle: %v", err)
+	}
+	if article.Canonical != "https://blog.example.com/posts/hello" {
+		t.Errorf("Canonical = %q", article.Canonical)
+	}
+	if article.WordCount < 30 {
+		t.Errorf("WordCount = %d, want the article paragraphs", article.WordCount)
+	}
+}
+
+func TestFixtureFetcherMissingFixture(t *testing.T) {
+	fetcher := &FixtureFetcher{Dir: t.TempDir()}
+	if _, err := fetcher.Fetch(context.Background(), "https://missing.example.com/feed", Validators{}, nil); err == nil {
+		t.Fatal("Fetch without a fixture succeeded, want an error")
+	}
+}
+
+func TestFixtureFetcherRecords(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, `<rss version="2.0"><channel><title>Recorded</title><item><title>One</title><guid>1</guid></item></channel></rss>`)
+	}))
+	defer server.Close()
+
+	recorder := NewHTTPFetcher()
+	recorder.MinHostInterval = 1
+	fetcher := &FixtureFetcher{Dir: t.TempDir(), Record: recorder}
+	feedURL := server.URL + "/feed"
+
+	for i := 0; i < 2; i++ {
+		result, err := fetcher.Fetch(context.Background(), feedURL, Validators{}, nil)
+		if err != nil {
+			t.Fatalf("Fetch %d: %v", i, err)
+		}
+		if result.Feed.Channel.Title != "Recorded" || len(result.Feed.Channel.Item) != 1 {
+			t.Fatalf("Fetch %d: got %+v", i, result.Feed.Channel)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("server got %d requests, want 1 recording followed by a replay", requests)
+	}
+	if _, err := os.Stat(fetcher.FixturePath(feedURL)); err != nil {
+		t.Errorf("fixture not written: %v", err)
+	}
+}
+
+func TestFileFetcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	feed := `<rss version="2.0"><channel><title>Local</title><item><title>One</title><link>one.html</link></item></channel></rss>`
+	if err := os.WriteFile(path, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	feedURL := "file://" + filepath.ToSlash(path)
+
+	result, err := FileFetcher{}.Fetch(context.Background(), feedURL, Validators{}, nil)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(result.Feed.Channel.Item) != 1 || result.Feed.Channel.Item[0].Title != "One" {
+		t.Fatalf("got %+v", result.Feed.Channel)
+	}
+
+	again, err := FileFetcher{}.Fetch(context.Background(), feedURL, result.Validators, nil)
+	if err != nil {
+		t.Fatalf("second Fetch: %v", err)
+	}
+	if !again.NotModified {
+		t.Error("unchanged file was not reported as not modified")
+	}
+
+	if _, err := (FileFetcher{MaxBodySize: 10}).Fetch(context.Background(), feedURL, Validators{}, nil); err == nil {
+		t.Error("file over MaxBodySize was read")
+	}
+}
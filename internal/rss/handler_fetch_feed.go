@@ -1,80 +1,703 @@
 package rss
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
 	"fmt"
-	"html"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+		Language    string `xml:"language"`
+		// Image is the feed's logo or icon: the RSS <image> URL, Atom
+		// <icon> or <logo>, or JSON Feed icon or favicon.
+		Image string    `xml:"-"`
+		Item  []RSSItem `xml:"item"`
+		// Hubs and Self come from rel="hub" and rel="self" links in the
+		// document or the HTTP Link header, and drive WebSub subscriptions.
+		Hubs []string `xml:"-"`
+		Self string   `xml:"-"`
+		// Base is the xml:base in scope for the channel, if any.
+		Base string `xml:"-"`
 	} `xml:"channel"`
 }
 
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string      `xml:"title"`
+	Link        string      `xml:"link"`
+	Description string      `xml:"description"`
+	Content     string      `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string      `xml:"pubDate"`
+	Guid        string      `xml:"guid"`
+	Author      string      `xml:"author"`
+	Creator     string      `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string    `xml:"category"`
+	Comments    string      `xml:"comments"`
+	CommentsRSS string      `xml:"http://wellformedweb.org/CommentAPI/ commentRss"`
+	Enclosures  []Enclosure `xml:"-"`
+	// Base is the item's own xml:base, relative to the channel's.
+	Base string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	// plainDescription and plainContent mark a Description or Content taken
+	// from a plain-text source, such as an Atom type="text" summary or JSON
+	// Feed content_text, which cleanItem escapes instead of parsing as HTML.
+	plainDescription bool
+	plainContent     bool
+	// received keeps fields as the feed sent them, before cleanItem resolved
+	// and sanitized them, so an item's id and content hash do not change with
+	// the cleaning.
+	received *receivedFields
+}
+
+type receivedFields struct {
+	Link        string
+	Title       string
+	Description string
+	Content     string
+}
+
+// AuthorName is the item's author as a display name: dc:creator when given,
+// else the name in an RSS "email (Name)" author, else the author as is.
+func (item RSSItem) AuthorName() string {
+	if creator := strings.TrimSpace(item.Creator); creator != "" {
+		return creator
+	}
+	author := strings.TrimSpace(item.Author)
+	if open := strings.Index(author, "("); open > 0 && strings.HasSuffix(author, ")") && strings.Contains(author[:open], "@") {
+		if name := strings.TrimSpace(author[open+1 : len(author)-1]); name != "" {
+			return name
+		}
+	}
+	return author
+}
+
+// CommentsURL is the item's comments page, or its comments feed when it has
+// no page.
+func (item RSSItem) CommentsURL() string {
+	if comments := strings.TrimSpace(item.Comments); comments != "" {
+		return comments
+	}
+	return strings.TrimSpace(item.CommentsRSS)
+}
+
+// CategoryNames returns the item's categories trimmed and without
+// duplicates, compared case-insensitively.
+func (item RSSItem) CategoryNames() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, category := range item.Categories {
+		category = strings.TrimSpace(category)
+		if category == "" || seen[strings.ToLower(category)] {
+			continue
+		}
+		seen[strings.ToLower(category)] = true
+		names = append(names, category)
+	}
+	return names
+}
+
+// StableID identifies an item within its feed: its guid or Atom id, or when
+// the feed gives none, a hash of its link and title as the feed sent them.
+func (item RSSItem) StableID() string {
+	if guid := strings.TrimSpace(item.Guid); guid != "" {
+		return guid
+	}
+	title := item.Title
+	if item.received != nil {
+		title = item.received.Title
+	}
+	sum := sha256.Sum256([]byte(item.ReceivedLink() + "\n" + title))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ReceivedLink is the item's link as the feed sent it, before it was
+// resolved against the item's base.
+func (item RSSItem) ReceivedLink() string {
+	if item.received != nil {
+		return item.received.Link
+	}
+	return item.Link
+}
+
+// ContentHash fingerprints the parts of an item a reader sees: its title,
+// description and content, as the feed sent them. A change in the hash means
+// the publisher edited the item after it was first saved; a change to the
+// sanitizer or to the feed's address does not.
+func (item RSSItem) ContentHash() string {
+	title, description, content := item.Title, item.Description, item.Content
+	if item.received != nil {
+		title, description, content = item.received.Title, item.received.Description, item.received.Content
+	}
+	sum := sha256.Sum256([]byte(title + "\x00" + description + "\x00" + content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Validators are the HTTP cache validators a server sent with a feed. They are
+// sent back as If-None-Match and If-Modified-Since on the next fetch.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// FetchResult is the outcome of a fetch. When the server answered 304 Not
+// Modified, NotModified is set and Feed is nil. PermanentURL is set when the
+// feed has moved permanently and holds its new address.
+type FetchResult struct {
+	Feed         *RSSFeed
+	Validators   Validators
+	NotModified  bool
+	Redirects    []Redirect
+	PermanentURL string
+	// StatusCode and Header are those of the response the feed was read
+	// from. A local file has no status code, and its Header only holds the
+	// Content-Type and Last-Modified it stands in for.
+	StatusCode int
+	Header     http.Header
+	// Body is the feed document as received. It is only kept when the items
+	// are collected in Feed rather than handed to visit.
+	Body []byte
+}
+
+// StatusError is returned by Fetch when the server answers with an error
+// status other than the ones that defer the host.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP error: %d", e.StatusCode)
+}
+
+// ParseError is returned by Fetch when the response is not a feed it can
+// decode. Result describes the response, without a Feed.
+type ParseError struct {
+	Result *FetchResult
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Redirect is one hop followed while fetching a feed.
+type Redirect struct {
+	From       string
+	To         string
+	StatusCode int
+}
+
+func (r Redirect) Permanent() bool {
+	return r.StatusCode == http.StatusMovedPermanently || r.StatusCode == http.StatusPermanentRedirect
 }
 
 var defaultClient = &http.Client{
 	Timeout: 6 * time.Second, // Optional: set a default timeout
 }
 
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+// fetchClient is HTTPFetcher's default client. It has no overall timeout,
+// which would cut off a feed whose items are still being streamed to the
+// caller; each fetch bounds its own request instead.
+var fetchClient = &http.Client{}
+
+// Fetcher retrieves and parses the feed at a URL. validators are the ones
+// returned by the previous fetch of the same feed. The feed's items are
+// handed to visit as they are parsed, or collected in FetchResult.Feed when
+// visit is nil. FetchPage retrieves any other document, such as the web page
+// behind a feed or a post, for feed discovery and full-text extraction.
+type Fetcher interface {
+	Fetch(ctx context.Context, feedURL string, validators Validators, visit ItemFunc) (*FetchResult, error)
+	FetchPage(ctx context.Context, pageURL string) (contentType string, body []byte, err error)
+}
+
+// SchemeFetcher picks a Fetcher by the scheme of the feed URL.
+type SchemeFetcher map[string]Fetcher
+
+func (f SchemeFetcher) Fetch(ctx context.Context, feedURL string, validators Validators, visit ItemFunc) (*FetchResult, error) {
+	fetcher, err := f.fetcher(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	return fetcher.Fetch(ctx, feedURL, validators, visit)
+}
+
+func (f SchemeFetcher) FetchPage(ctx context.Context, pageURL string) (string, []byte, error) {
+	fetcher, err := f.fetcher(pageURL)
+	if err != nil {
+		return "", nil, err
+	}
+	return fetcher.FetchPage(ctx, pageURL)
+}
+
+func (f SchemeFetcher) fetcher(rawURL string) (Fetcher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid feed URL", err)
+	}
+	fetcher, ok := f[strings.ToLower(u.Scheme)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported feed URL scheme: %q", u.Scheme)
+	}
+	return fetcher, nil
+}
+
+// HTTPFetcher fetches feeds over HTTP with conditional requests, spacing
+// requests to each host by MinHostInterval. Build it with NewHTTPFetcher,
+// which fills in the defaults.
+type HTTPFetcher struct {
+	Client *http.Client
+	// MaxBodySize caps how many bytes of a response are read after
+	// decompression.
+	MaxBodySize int64
+	// MinHostInterval is the minimum spacing between two requests to the
+	// same host.
+	MinHostInterval time.Duration
+	// RespectRobots makes the fetcher consult the host's robots.txt and
+	// refuse URLs it disallows for gator.
+	RespectRobots bool
+}
+
+func NewHTTPFetcher() *HTTPFetcher {
+	return &HTTPFetcher{
+		Client:          fetchClient,
+		MaxBodySize:     DefaultMaxBodySize,
+		MinHostInterval: DefaultMinHostInterval,
+	}
+}
+
+// Fetch waits for its turn on the feed's host within ctx, and only then
+// starts the 6 second timeout of the request itself. The timeout ends with
+// the response headers: reading the body, and handing its items to visit,
+// is bounded by ctx alone.
+func (f *HTTPFetcher) Fetch(ctx context.Context, feedURL string, validators Validators, visit ItemFunc) (*FetchResult, error) {
+	if err := f.wait(ctx, feedURL); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+	req, err := newRequest(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	setValidators(req, validators)
+	timeout := time.AfterFunc(6*time.Second, cancel)
+	resp, redirects, err := f.get(req)
+	if !timeout.Stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: no response from %s within 6 seconds", context.DeadlineExceeded, feedURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	return resultFromResponse(resp, validators, redirects, f.MaxBodySize, visit)
+}
+
+// FetchPage downloads a web page under the same politeness rules as a feed.
+func (f *HTTPFetcher) FetchPage(ctx context.Context, pageURL string) (string, []byte, error) {
+	if err := f.wait(ctx, pageURL); err != nil {
+		return "", nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 6*time.Second)
+
+	defer cancel()
+	req, err := newPageRequest(ctx, pageURL)
+	if err != nil {
+		return "", nil, err
+	}
+	resp, _, err := f.get(req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	defer resp.Body.Close()
+	return pageFromResponse(resp, f.MaxBodySize)
+}
+
+// wait checks robots.txt when RespectRobots is set and waits for the URL's
+// turn on its host. It comes before every get.
+func (f *HTTPFetcher) wait(ctx context.Context, feedURL string) error {
+	u, err := parseFeedURL(feedURL)
+	if err != nil {
+		return err
+	}
+	if f.RespectRobots {
+		if err := f.robotsAllow(ctx, u); err != nil {
+			return err
+		}
+	}
+	interval := f.MinHostInterval
+	if interval <= 0 {
+		interval = DefaultMinHostInterval
+	}
+	return waitForHost(ctx, u.Host, interval)
+}
+
+func (f *HTTPFetcher) client() *http.Client {
+	if f.Client != nil {
+		return f.Client
+	}
+	return fetchClient
+}
+
+func parseFeedURL(feedURL string) (*url.URL, error) {
 	if feedURL == "" {
 		return nil, fmt.Errorf("feed URL cannot be empty")
 	}
-
 	u, err := url.Parse(feedURL)
 	if err != nil || u.Scheme == "" || u.Host == "" {
 		return nil, fmt.Errorf("%w: invalid feed URL", err)
 	}
+	return u, nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+// get sends a request built by newRequest or newPageRequest, following
+// redirects and recording each hop. The caller closes the response body.
+func (f *HTTPFetcher) get(req *http.Request) (*http.Response, []Redirect, error) {
+	var redirects []Redirect
+	client := *f.client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		redirects = append(redirects, Redirect{
+			From:       via[len(via)-1].URL.String(),
+			To:         req.URL.String(),
+			StatusCode: req.Response.StatusCode,
+		})
+		return nil
+	}
 
-	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed sending response Body %w", err)
+	}
+	return resp, redirects, nil
+}
+
+// resultFromResponse turns a feed response, live or replayed from a fixture,
+// into a FetchResult.
+func resultFromResponse(resp *http.Response, validators Validators, redirects []Redirect, maxSize int64, visit ItemFunc) (*FetchResult, error) {
+	if resp.StatusCode == http.StatusNotModified {
+		return &FetchResult{
+			Validators:   responseValidators(resp, validators),
+			NotModified:  true,
+			Redirects:    redirects,
+			PermanentURL: permanentURL(redirects),
+			StatusCode:   resp.StatusCode,
+			Header:       resp.Header,
+		}, nil
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		host := resp.Request.URL.Host
+		if len(redirects) > 0 {
+			if u, err := url.Parse(redirects[0].From); err == nil {
+				host = u.Host
+			}
+		}
+		return nil, deferHost(host, resp)
+	}
+	if resp.StatusCode > 299 {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := readBody(resp, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FetchResult{
+		Validators:   responseValidators(resp, Validators{}),
+		Redirects:    redirects,
+		PermanentURL: permanentURL(redirects),
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header,
+	}
+	if visit == nil {
+		if result.Body, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(result.Body)
+	}
+
+	sink := &itemSink{feedURL: resp.Request.URL, clean: true, visit: visit}
+	rssFeed, err := decodeFeed(resp.Header.Get("Content-Type"), body, sink)
+	if err != nil {
+		return nil, &ParseError{Result: result, Err: err}
+	}
+
+	for rel, hrefs := range linkHeaderRels(resp.Header.Values("Link")) {
+		for _, href := range hrefs {
+			rssFeed.addLinkRel(rel, resolveAgainst(resp.Request.URL, href))
+		}
+	}
+
+	result.Feed = rssFeed
+	return result, nil
+}
+
+// permanentURL returns the URL reached by the leading run of permanent
+// redirects. A temporary hop ends the run, since what follows it may change.
+func permanentURL(redirects []Redirect) string {
+	var target string
+	for _, redirect := range redirects {
+		if !redirect.Permanent() {
+			break
+		}
+		target = redirect.To
+	}
+	return target
+}
+
+func newRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed getting reqest: %w\nmethod: %v\nurl: %s", err, http.MethodGet, feedURL)
+		return nil, fmt.Errorf("failed getting reqest: %w\nmethod: %v\nurl: %s", err, http.MethodGet, rawURL)
 	}
 	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	return req, nil
+}
 
-	resp, err := defaultClient.Do(req)
+// newPageRequest is newRequest for a web page, preferring HTML.
+func newPageRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	req, err := newRequest(ctx, rawURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed sending response Body %w", err)
+		return nil, err
 	}
+	req.Header.Set("Accept", "text/html, "+req.Header.Get("Accept"))
+	return req, nil
+}
 
-	defer resp.Body.Close()
-	if resp.StatusCode > 299 {
-		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+// setValidators makes req conditional on the validators of the last fetch.
+func setValidators(req *http.Request, validators Validators) {
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
 	}
+}
 
-	data, err := io.ReadAll(resp.Body)
+// responseValidators reads the cache validators from a response, keeping the
+// previous value for any header the server did not send.
+func responseValidators(resp *http.Response, previous Validators) Validators {
+	validators := previous
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		validators.ETag = etag
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		validators.LastModified = lastModified
+	}
+	return validators
+}
+
+// ParseFeed decodes a feed body in any supported format, for callers such as
+// the WebSub listener that receive feed content without fetching it.
+// Relative links are resolved against feedURL when xml:base and the channel
+// link leave them relative. Items are handed to visit as they are decoded,
+// or collected in the feed when visit is nil.
+func ParseFeed(contentType, feedURL string, body io.Reader, visit ItemFunc) (*RSSFeed, error) {
+	base, err := url.Parse(feedURL)
+	if err != nil || !base.IsAbs() {
+		base = nil
+	}
+	return decodeFeed(contentType, body, &itemSink{feedURL: base, clean: true, visit: visit})
+}
+
+// parseFeed decodes a feed that has already been read into memory, keeping
+// its items as they appear in the document.
+func parseFeed(contentType string, data []byte) (*RSSFeed, error) {
+	return decodeFeed(contentType, bytes.NewReader(data), &itemSink{})
+}
+
+// ItemFunc is handed the items of a feed one at a time, cleaned, while the
+// feed is decoded, so a large feed is never held in memory as a whole. An
+// error stops decoding and is returned by the fetch.
+type ItemFunc func(item RSSItem) error
+
+// itemSink receives the items of a feed as they are decoded. With clean set,
+// each item is cleaned against the channel as decoded so far. Items go to
+// visit, or are collected in the feed when visit is nil.
+type itemSink struct {
+	feed    *RSSFeed
+	feedURL *url.URL
+	clean   bool
+	visit   ItemFunc
+}
+
+func (s *itemSink) add(item RSSItem) error {
+	if s.clean {
+		cleanItem(&item, itemBase(s.feed, s.feedURL))
+	}
+	if s.visit == nil {
+		s.feed.Channel.Item = append(s.feed.Channel.Item, item)
+		return nil
+	}
+	return s.visit(item)
+}
+
+// decodeFeed sniffs the content type and the document's root element and
+// decodes it with the matching format, always returning the RSS item model.
+// The body is parsed token by token and each item is passed to sink as soon
+// as it is decoded. Channel fields that come after the items in the document
+// are not yet known when those items are cleaned.
+func decodeFeed(contentType string, body io.Reader, sink *itemSink) (*RSSFeed, error) {
+	rssFeed := &RSSFeed{}
+	sink.feed = rssFeed
+
+	buffered := bufio.NewReaderSize(body, sniffLen)
+	head, err := peek(buffered)
 	if err != nil {
 		return nil, fmt.Errorf("failed reading response: %w", err)
 	}
-	var rssFeed RSSFeed
-	if err = xml.Unmarshal([]byte(data), &rssFeed); err != nil {
-		return nil, fmt.Errorf("%w: failed to decode data", err)
+
+	r, err := utf8Reader(contentType, head, buffered)
+	if err != nil {
+		return nil, err
+	}
+
+	var rootLang string
+	if isJSONFeed(contentType, head) {
+		err = parseJSONFeed(r, sink)
+	} else {
+		decoder := newXMLDecoder(r)
+		var root xml.StartElement
+		root, err = rootElement(decoder)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to decode data", err)
+		}
+		rssFeed.Channel.Base = xmlBase(root)
+		rootLang = xmlLang(root)
+
+		switch {
+		case root.Name.Local == "feed" && root.Name.Space == atomNamespace:
+			err = parseAtom(decoder, sink)
+		case root.Name.Local == "RDF" && root.Name.Space == rdfNamespace:
+			err = parseRDF(decoder, sink)
+		case root.Name.Local == "rss":
+			err = parseRSS(decoder, sink)
+		default:
+			return nil, fmt.Errorf("unrecognized feed format: <%s> root element", root.Name.Local)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(rssFeed.Channel.Language) == "" {
+		rssFeed.Channel.Language = rootLang
 	}
+	rssFeed.Channel.Language = strings.TrimSpace(rssFeed.Channel.Language)
+	if sink.clean {
+		cleanChannel(rssFeed, sink.feedURL)
+	}
+	return rssFeed, nil
+}
+
+func parseRSS(decoder *xml.Decoder, sink *itemSink) error {
+	rssFeed := sink.feed
+	err := eachChild(decoder, func(start xml.StartElement) error {
+		if start.Name.Local != "channel" {
+			return decoder.Skip()
+		}
+		rssFeed.Channel.Base = joinBase(rssFeed.Channel.Base, xmlBase(start))
+		return eachChild(decoder, func(start xml.StartElement) error {
+			// Only un-namespaced channel fields count, so an <atom:link> or
+			// <itunes:title> cannot overwrite the RSS ones.
+			switch {
+			case start.Name.Local == "item":
+				var item RSSItem
+				if err := decoder.DecodeElement(&item, &start); err != nil {
+					return err
+				}
+				return sink.add(item)
+			case start.Name.Space == atomNamespace && start.Name.Local == "link":
+				var link atomLink
+				if err := decoder.DecodeElement(&link, &start); err != nil {
+					return err
+				}
+				rssFeed.addLinkRel(link.Rel, link.Href)
+				return nil
+			case start.Name.Space != "":
+				return decoder.Skip()
+			case start.Name.Local == "title":
+				return decoder.DecodeElement(&rssFeed.Channel.Title, &start)
+			case start.Name.Local == "link":
+				return decoder.DecodeElement(&rssFeed.Channel.Link, &start)
+			case start.Name.Local == "description":
+				return decoder.DecodeElement(&rssFeed.Channel.Description, &start)
+			case start.Name.Local == "language":
+				return decoder.DecodeElement(&rssFeed.Channel.Language, &start)
+			case start.Name.Local == "image":
+				var image feedImage
+				if err := decoder.DecodeElement(&image, &start); err != nil {
+					return err
+				}
+				rssFeed.Channel.Image = strings.TrimSpace(image.URL)
+				return nil
+			}
+			return decoder.Skip()
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("%w: failed to decode data", err)
+	}
+	return nil
+}
+
+// feedImage is the <image> of an RSS channel, of which only the URL is used.
+type feedImage struct {
+	URL string `xml:"url"`
+}
 
-	rssFeed.Channel.Title = html.UnescapeString(rssFeed.Channel.Title)
-	rssFeed.Channel.Description = html.UnescapeString(rssFeed.Channel.Description)
+func rootElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
 
-	for i := range rssFeed.Channel.Item {
-		rssFeed.Channel.Item[i].Title = html.UnescapeString(rssFeed.Channel.Item[i].Title)
-		rssFeed.Channel.Item[i].Description = html.UnescapeString(rssFeed.Channel.Item[i].Description)
+// eachChild calls visit for every child element of the element whose start
+// tag was just read, returning once its end tag is reached. visit must
+// consume the whole child, with DecodeElement or Skip.
+func eachChild(decoder *xml.Decoder, visit func(start xml.StartElement) error) error {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := visit(t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
 	}
-	return &rssFeed, nil
 }
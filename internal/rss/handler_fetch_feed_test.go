@@ -0,0 +1,101 @@
+package rss
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseFeedVisitsItems(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"rss", "application/rss+xml", `<rss version="2.0"><channel><title>T</title><link>https://example.com/</link>
+<item><title>One</title><link>/one</link></item><item><title>Two</title><link>/two</link></item></channel></rss>`},
+		{"atom", "application/atom+xml", `<feed xmlns="http://www.w3.org/2005/Atom"><title>T</title><link href="https://example.com/"/>
+<entry><title>One</title><link href="/one"/></entry><entry><title>Two</title><link href="/two"/></entry></feed>`},
+		{"json feed", "application/feed+json", `{"version": "https://jsonfeed.org/version/1.1", "title": "T", "home_page_url": "https://example.com/",
+"items": [{"id": "1", "title": "One", "url": "/one"}, {"id": "2", "title": "Two", "url": "/two"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var links []string
+			feed, err := ParseFeed(tt.contentType, "https://example.com/feed", strings.NewReader(tt.body), func(item RSSItem) error {
+				links = append(links, item.Link)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ParseFeed: %v", err)
+			}
+			if want := []string{"https://example.com/one", "https://example.com/two"}; strings.Join(links, " ") != strings.Join(want, " ") {
+				t.Errorf("visited links %q, want %q", links, want)
+			}
+			if len(feed.Channel.Item) != 0 {
+				t.Errorf("feed kept %d items, want them handed to visit only", len(feed.Channel.Item))
+			}
+			if feed.Channel.Title != "T" {
+				t.Errorf("channel Title = %q, want %q", feed.Channel.Title, "T")
+			}
+		})
+	}
+}
+
+func TestParseFeedVisitError(t *testing.T) {
+	stop := errors.New("stop")
+	visited := 0
+	_, err := ParseFeed("application/rss+xml", "https://example.com/feed", strings.NewReader(`<rss version="2.0"><channel>
+<item><title>One</title></item><item><title>Two</title></item></channel></rss>`), func(item RSSItem) error {
+		visited++
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("ParseFeed error = %v, want the error returned by visit", err)
+	}
+	if visited != 1 {
+		t.Errorf("visit called %d times, want decoding to stop after the first item", visited)
+	}
+}
+
+func TestStableIDUsesReceivedLink(t *testing.T) {
+	body := `<rss version="2.0"><channel><link>https://example.com/</link>
+<item><title>Caf&amp;eacute;</title><link>/posts/1</link></item></channel></rss>`
+	raw, err := parseFeed("application/rss+xml", []byte(body))
+	if err != nil {
+		t.Fatalf("parseFeed: %v", err)
+	}
+	cleaned, err := ParseFeed("application/rss+xml", "https://example.com/feed", strings.NewReader(body), nil)
+	if err != nil {
+		t.Fatalf("ParseFeed: %v", err)
+	}
+
+	item := cleaned.Channel.Item[0]
+	if item.Link != "https://example.com/posts/1" || item.ReceivedLink() != "/posts/1" {
+		t.Errorf("Link = %q, ReceivedLink = %q", item.Link, item.ReceivedLink())
+	}
+	if got, want := item.StableID(), raw.Channel.Item[0].StableID(); got != want {
+		t.Errorf("StableID of the cleaned item = %q, want %q as before link resolution", got, want)
+	}
+}
+
+func TestContentHashUsesReceivedContent(t *testing.T) {
+	body := `<rss version="2.0"><channel><link>https://example.com/</link>
+<item><title>One</title><description>&lt;p&gt;Hi &lt;a href="/a" onclick="x()"&gt;there&lt;/a&gt;&lt;/p&gt;</description></item></channel></rss>`
+	raw, err := parseFeed("application/rss+xml", []byte(body))
+	if err != nil {
+		t.Fatalf("parseFeed: %v", err)
+	}
+	cleaned, err := ParseFeed("application/rss+xml", "https://example.com/feed", strings.NewReader(body), nil)
+	if err != nil {
+		t.Fatalf("ParseFeed: %v", err)
+	}
+
+	item := cleaned.Channel.Item[0]
+	if item.Description == raw.Channel.Item[0].Description {
+		t.Fatalf("description was not sanitized: %q", item.Description)
+	}
+	if got, want := item.ContentHash(), raw.Channel.Item[0].ContentHash(); got != want {
+		t.Errorf("ContentHash of the cleaned item = %q, want %q as received", got, want)
+	}
+}
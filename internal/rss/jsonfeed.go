@@ -0,0 +1,248 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+)
+
+// jsonFeed is the top level of a JSON Feed without its items, which
+// parseJSONFeed decodes one at a time.
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url"`
+	FeedURL     string       `json:"feed_url"`
+	Hubs        []jsonHub    `json:"hubs"`
+	Description string       `json:"description"`
+	Icon        string       `json:"icon"`
+	Favicon     string       `json:"favicon"`
+	Language    string       `json:"language"`
+	Authors     []jsonAuthor `json:"authors"`
+	Author      *jsonAuthor  `json:"author"` // JSON Feed 1.0
+}
+
+type jsonFeedItem struct {
+	ID            json.RawMessage  `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonAuthor     `json:"authors"`
+	Author        *jsonAuthor      `json:"author"` // JSON Feed 1.0
+	Image         string           `json:"image"`
+	Attachments   []jsonAttachment `json:"attachments"`
+	Tags          []string         `json:"tags"`
+}
+
+type jsonAttachment struct {
+	URL               string  `json:"url"`
+	MimeType          string  `json:"mime_type"`
+	SizeInBytes       float64 `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+type jsonHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// isJSONFeed reports whether a response should be decoded as JSON Feed, based
+// on the Content-Type header or, when that is missing or generic, the start
+// of the body.
+func isJSONFeed(contentType string, head []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/feed+json", "application/json":
+		return true
+	}
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	return bytes.HasPrefix(bytes.TrimSpace(head), []byte("{"))
+}
+
+// parseJSONFeed decodes a JSON Feed token by token, handing each element of
+// "items" to sink as soon as it is decoded. The feed's other members are
+// decoded as they come, so an item only sees the home page and author of
+// the feed when they precede "items", as they usually do.
+func parseJSONFeed(r io.Reader, sink *itemSink) error {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return fmt.Errorf("%w: failed to decode json feed data", err)
+	}
+
+	var feed jsonFeed
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("%w: failed to decode json feed data", err)
+		}
+		key, _ := token.(string)
+		if key != "items" {
+			if err := feed.decodeMember(decoder, key); err != nil {
+				return fmt.Errorf("%w: failed to decode json feed data", err)
+			}
+			if key == "version" && !supportedJSONFeedVersion(feed.Version) {
+				return fmt.Errorf("unsupported json feed version: %q", feed.Version)
+			}
+			feed.channel(sink.feed)
+			continue
+		}
+
+		if err := expectDelim(decoder, '['); err != nil {
+			return fmt.Errorf("%w: failed to decode json feed items", err)
+		}
+		for decoder.More() {
+			var item jsonFeedItem
+			if err := decoder.Decode(&item); err != nil {
+				return fmt.Errorf("%w: failed to decode json feed item", err)
+			}
+			if err := sink.add(item.item(authorNames(feed.Authors, feed.Author))); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return fmt.Errorf("%w: failed to decode json feed items", err)
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return fmt.Errorf("%w: failed to decode json feed data", err)
+	}
+	if !supportedJSONFeedVersion(feed.Version) {
+		return fmt.Errorf("unsupported json feed version: %q", feed.Version)
+	}
+
+	sink.feed.addLinkRel("self", feed.FeedURL)
+	for _, hub := range feed.Hubs {
+		if strings.EqualFold(hub.Type, "WebSub") {
+			sink.feed.addLinkRel("hub", hub.URL)
+		}
+	}
+	return nil
+}
+
+func supportedJSONFeedVersion(version string) bool {
+	return strings.HasPrefix(version, "https://jsonfeed.org/version/")
+}
+
+// decodeMember decodes the value of the top-level member key into the
+// matching field of feed. Unknown members are skipped.
+func (feed *jsonFeed) decodeMember(decoder *json.Decoder, key string) error {
+	var value json.RawMessage
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	member, err := json.Marshal(map[string]json.RawMessage{key: value})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(member, feed)
+}
+
+// channel copies the feed's metadata decoded so far into the channel.
+func (feed *jsonFeed) channel(rssFeed *RSSFeed) {
+	rssFeed.Channel.Title = feed.Title
+	rssFeed.Channel.Link = feed.HomePageURL
+	rssFeed.Channel.Description = feed.Description
+	rssFeed.Channel.Language = feed.Language
+	rssFeed.Channel.Image = feed.Favicon
+	if rssFeed.Channel.Image == "" {
+		rssFeed.Channel.Image = feed.Icon
+	}
+}
+
+func (item jsonFeedItem) item(feedAuthor string) RSSItem {
+	content, plainContent := item.ContentHTML, false
+	if content == "" {
+		content, plainContent = item.ContentText, true
+	}
+	description, plainDescription := item.Summary, false
+	if description == "" {
+		description, plainDescription = content, plainContent
+	}
+
+	pubDate := item.DatePublished
+	if pubDate == "" {
+		pubDate = item.DateModified
+	}
+
+	author := authorNames(item.Authors, item.Author)
+	if author == "" {
+		author = feedAuthor
+	}
+
+	var enclosures []Enclosure
+	for _, attachment := range item.Attachments {
+		enclosures = append(enclosures, Enclosure{
+			URL:       attachment.URL,
+			Type:      attachment.MimeType,
+			Length:    int64(attachment.SizeInBytes),
+			Duration:  time.Duration(attachment.DurationInSeconds * float64(time.Second)),
+			Thumbnail: item.Image,
+		})
+	}
+
+	return RSSItem{
+		Title:       item.Title,
+		Link:        item.URL,
+		Description: description,
+		Content:     content,
+		PubDate:     pubDate,
+		Guid:        jsonFeedID(item.ID),
+		Author:      author,
+		Categories:  item.Tags,
+		Enclosures:  enclosures,
+
+		plainDescription: plainDescription,
+		plainContent:     plainContent,
+	}
+}
+
+// expectDelim reads the next token and fails unless it is delim.
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %q, got %v", delim, token)
+	}
+	return nil
+}
+
+func authorNames(authors []jsonAuthor, legacy *jsonAuthor) string {
+	if legacy != nil {
+		authors = append(authors, *legacy)
+	}
+	var names []string
+	for _, author := range authors {
+		if author.Name != "" {
+			names = append(names, author.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// jsonFeedID accepts both string and numeric ids; the spec requires a string
+// but plenty of generators emit numbers.
+func jsonFeedID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+	return strings.TrimSpace(string(raw))
+}
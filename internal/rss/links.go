@@ -0,0 +1,122 @@
+package rss
+
+import (
+	"encoding/xml"
+	"net/url"
+	"strings"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// xmlBase returns the xml:base attribute of an element.
+func xmlBase(start xml.StartElement) string {
+	for _, a := range start.Attr {
+		if a.Name.Space == xmlNamespace && a.Name.Local == "base" {
+			return strings.TrimSpace(a.Value)
+		}
+	}
+	return ""
+}
+
+// xmlLang returns the xml:lang attribute of an element.
+func xmlLang(start xml.StartElement) string {
+	for _, a := range start.Attr {
+		if a.Name.Space == xmlNamespace && a.Name.Local == "lang" {
+			return strings.TrimSpace(a.Value)
+		}
+	}
+	return ""
+}
+
+// joinBase resolves a nested xml:base against the one in scope around it.
+// Either may still be relative to the document's own URL.
+func joinBase(outer, inner string) string {
+	if inner == "" {
+		return outer
+	}
+	if outer == "" {
+		return inner
+	}
+	outerURL, err := url.Parse(outer)
+	if err != nil {
+		return inner
+	}
+	return resolveAgainst(outerURL, inner)
+}
+
+// resolveLinks makes the channel's links absolute against its xml:base,
+// taken relative to the feed URL.
+func resolveLinks(rssFeed *RSSFeed, feedURL *url.URL) {
+	base := channelXMLBase(rssFeed, feedURL)
+	channel := &rssFeed.Channel
+	channel.Link = resolveRef(base, channel.Link)
+	channel.Self = resolveRef(base, channel.Self)
+	channel.Image = resolveRef(base, channel.Image)
+	for i := range channel.Hubs {
+		channel.Hubs[i] = resolveRef(base, channel.Hubs[i])
+	}
+}
+
+// itemBase returns the base the channel's items are resolved against: its
+// xml:base, else its link, else feedURL, each taken relative to feedURL. The
+// result is nil when nothing absolute is known. It does not change the
+// channel, so it can be asked while the feed is still being decoded.
+func itemBase(rssFeed *RSSFeed, feedURL *url.URL) *url.URL {
+	base := channelXMLBase(rssFeed, feedURL)
+	if rssFeed.Channel.Base == "" && strings.TrimSpace(rssFeed.Channel.Link) != "" {
+		if link, err := url.Parse(resolveRef(base, rssFeed.Channel.Link)); err == nil && link.IsAbs() {
+			base = link
+		}
+	}
+	if base != nil && !base.IsAbs() {
+		return nil
+	}
+	return base
+}
+
+func channelXMLBase(rssFeed *RSSFeed, feedURL *url.URL) *url.URL {
+	if rssFeed.Channel.Base != "" {
+		return resolveBase(feedURL, rssFeed.Channel.Base)
+	}
+	return feedURL
+}
+
+// resolveItemLinks makes an item's links and media absolute against its own
+// xml:base, taken relative to channelBase, and returns the base used so the
+// item's HTML can be resolved the same way.
+func resolveItemLinks(item *RSSItem, channelBase *url.URL) *url.URL {
+	base := channelBase
+	if item.Base != "" {
+		base = resolveBase(channelBase, item.Base)
+	}
+	if base != nil && !base.IsAbs() {
+		base = nil
+	}
+
+	item.Link = resolveRef(base, item.Link)
+	item.Comments = resolveRef(base, item.Comments)
+	item.CommentsRSS = resolveRef(base, item.CommentsRSS)
+	for i := range item.Enclosures {
+		item.Enclosures[i].URL = resolveRef(base, item.Enclosures[i].URL)
+		item.Enclosures[i].Thumbnail = resolveRef(base, item.Enclosures[i].Thumbnail)
+	}
+	return base
+}
+
+func resolveBase(base *url.URL, ref string) *url.URL {
+	resolved, err := url.Parse(resolveRef(base, ref))
+	if err != nil {
+		return base
+	}
+	return resolved
+}
+
+// resolveRef resolves a possibly relative reference, leaving it untouched
+// when there is no base or it does not parse.
+func resolveRef(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ref
+	}
+	return resolveAgainst(base, ref)
+}
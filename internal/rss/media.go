@@ -0,0 +1,255 @@
+package rss
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Enclosure is a media file attached to an item: a podcast episode, a video,
+// or an image.
+type Enclosure struct {
+	URL       string
+	Type      string
+	Length    int64
+	Duration  time.Duration
+	Thumbnail string
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// mediaElements are the Media RSS and iTunes extensions found on both RSS
+// items and Atom entries. Numeric attributes are kept as strings because
+// feeds routinely leave them empty or malformed.
+type mediaElements struct {
+	Contents       []mediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnails     []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Groups         []mediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+	ItunesDuration string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ItunesImage    struct {
+		Href string `xml:"href,attr"`
+	} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+}
+
+type mediaGroup struct {
+	Contents   []mediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type mediaContent struct {
+	URL        string           `xml:"url,attr"`
+	Type       string           `xml:"type,attr"`
+	Medium     string           `xml:"medium,attr"`
+	FileSize   string           `xml:"fileSize,attr"`
+	Duration   string           `xml:"duration,attr"`
+	Thumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type mediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+const (
+	contentNamespace = "http://purl.org/rss/1.0/modules/content/"
+	wfwNamespace     = "http://wellformedweb.org/CommentAPI/"
+	mediaNamespace   = "http://search.yahoo.com/mrss/"
+	itunesNamespace  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+)
+
+// UnmarshalXML decodes an RSS item and collects its <enclosure>, Media RSS
+// and iTunes elements into Enclosures. As with channel fields, the core item
+// fields are only taken from un-namespaced elements, so a <media:title>,
+// <atom:link> or <itunes:author> cannot overwrite them.
+func (item *RSSItem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*item = RSSItem{Base: xmlBase(start)}
+	var rssEnclosures []rssEnclosure
+	var media mediaElements
+
+	err := eachChild(d, func(child xml.StartElement) error {
+		var field *string
+		switch child.Name {
+		case xml.Name{Local: "title"}:
+			field = &item.Title
+		case xml.Name{Local: "link"}:
+			field = &item.Link
+		case xml.Name{Local: "description"}:
+			field = &item.Description
+		case xml.Name{Local: "pubDate"}:
+			field = &item.PubDate
+		case xml.Name{Local: "guid"}:
+			field = &item.Guid
+		case xml.Name{Local: "author"}:
+			field = &item.Author
+		case xml.Name{Local: "comments"}:
+			field = &item.Comments
+		case xml.Name{Space: contentNamespace, Local: "encoded"}:
+			field = &item.Content
+		case xml.Name{Space: dublinCoreNamespace, Local: "creator"}:
+			field = &item.Creator
+		case xml.Name{Space: wfwNamespace, Local: "commentRss"}:
+			field = &item.CommentsRSS
+		case xml.Name{Space: itunesNamespace, Local: "duration"}:
+			field = &media.ItunesDuration
+		case xml.Name{Local: "category"}:
+			var category string
+			if err := d.DecodeElement(&category, &child); err != nil {
+				return err
+			}
+			item.Categories = append(item.Categories, category)
+			return nil
+		case xml.Name{Local: "enclosure"}:
+			var enclosure rssEnclosure
+			if err := d.DecodeElement(&enclosure, &child); err != nil {
+				return err
+			}
+			rssEnclosures = append(rssEnclosures, enclosure)
+			return nil
+		case xml.Name{Space: mediaNamespace, Local: "content"}:
+			var content mediaContent
+			if err := d.DecodeElement(&content, &child); err != nil {
+				return err
+			}
+			media.Contents = append(media.Contents, content)
+			return nil
+		case xml.Name{Space: mediaNamespace, Local: "thumbnail"}:
+			var thumbnail mediaThumbnail
+			if err := d.DecodeElement(&thumbnail, &child); err != nil {
+				return err
+			}
+			media.Thumbnails = append(media.Thumbnails, thumbnail)
+			return nil
+		case xml.Name{Space: mediaNamespace, Local: "group"}:
+			var group mediaGroup
+			if err := d.DecodeElement(&group, &child); err != nil {
+				return err
+			}
+			media.Groups = append(media.Groups, group)
+			return nil
+		case xml.Name{Space: itunesNamespace, Local: "image"}:
+			return d.DecodeElement(&media.ItunesImage, &child)
+		default:
+			return d.Skip()
+		}
+		return d.DecodeElement(field, &child)
+	})
+	if err != nil {
+		return err
+	}
+
+	var enclosures []Enclosure
+	for _, enclosure := range rssEnclosures {
+		enclosures = append(enclosures, Enclosure{
+			URL:    strings.TrimSpace(enclosure.URL),
+			Type:   enclosure.Type,
+			Length: parseLength(enclosure.Length),
+		})
+	}
+	item.Enclosures = media.enclosures(enclosures)
+	return nil
+}
+
+// enclosures merges the plain enclosures of an item with its Media RSS
+// content, and fills in durations and thumbnails from the item-level
+// Media RSS and iTunes elements.
+func (m mediaElements) enclosures(enclosures []Enclosure) []Enclosure {
+	contents := m.Contents
+	thumbnails := m.Thumbnails
+	for _, group := range m.Groups {
+		contents = append(contents, group.Contents...)
+		thumbnails = append(thumbnails, group.Thumbnails...)
+	}
+
+	seen := map[string]int{}
+	for i, enclosure := range enclosures {
+		seen[enclosure.URL] = i
+	}
+	for _, content := range contents {
+		url := strings.TrimSpace(content.URL)
+		if url == "" {
+			continue
+		}
+		enclosure := Enclosure{
+			URL:      url,
+			Type:     content.Type,
+			Length:   parseLength(content.FileSize),
+			Duration: parseDuration(content.Duration),
+		}
+		if enclosure.Type == "" {
+			enclosure.Type = content.Medium
+		}
+		if len(content.Thumbnails) > 0 {
+			enclosure.Thumbnail = content.Thumbnails[0].URL
+		}
+
+		if i, ok := seen[url]; ok {
+			enclosures[i] = mergeEnclosure(enclosures[i], enclosure)
+			continue
+		}
+		seen[url] = len(enclosures)
+		enclosures = append(enclosures, enclosure)
+	}
+
+	thumbnail := m.ItunesImage.Href
+	if len(thumbnails) > 0 {
+		thumbnail = thumbnails[0].URL
+	}
+	duration := parseDuration(m.ItunesDuration)
+
+	for i := range enclosures {
+		if enclosures[i].Thumbnail == "" {
+			enclosures[i].Thumbnail = strings.TrimSpace(thumbnail)
+		}
+		if enclosures[i].Duration == 0 {
+			enclosures[i].Duration = duration
+		}
+	}
+	return enclosures
+}
+
+func mergeEnclosure(base, extra Enclosure) Enclosure {
+	if base.Type == "" {
+		base.Type = extra.Type
+	}
+	if base.Length == 0 {
+		base.Length = extra.Length
+	}
+	if base.Duration == 0 {
+		base.Duration = extra.Duration
+	}
+	if base.Thumbnail == "" {
+		base.Thumbnail = extra.Thumbnail
+	}
+	return base
+}
+
+func parseLength(value string) int64 {
+	length, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || length < 0 {
+		return 0
+	}
+	return length
+}
+
+// parseDuration reads media:content and itunes:duration values, which are
+// either plain seconds or [[HH:]MM:]SS.
+func parseDuration(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
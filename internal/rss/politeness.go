@@ -0,0 +1,133 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMinHostInterval is the minimum spacing between two requests to the
+// same host, unless a fetcher sets its own MinHostInterval.
+const DefaultMinHostInterval = 2 * time.Second
+
+// DefaultRetryAfter is how long a host is left alone after a 429 or 503
+// response that carries no usable Retry-After header.
+var DefaultRetryAfter = 5 * time.Minute
+
+var ErrDisallowedByRobots = errors.New("feed disallowed by robots.txt")
+
+// HostDeferredError reports that a host asked us to back off, through a 429
+// or 503 response, and that no feed on it should be fetched before Until.
+type HostDeferredError struct {
+	Host       string
+	Until      time.Time
+	StatusCode int
+}
+
+func (e *HostDeferredError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("host %s deferred until %s", e.Host, e.Until.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("HTTP error: %d, host %s deferred until %s", e.StatusCode, e.Host, e.Until.Format(time.RFC3339))
+}
+
+type hostState struct {
+	nextRequest  time.Time
+	blockedUntil time.Time
+	robots       *robotsRules
+	robotsAt     time.Time
+}
+
+var hosts = struct {
+	sync.Mutex
+	state map[string]*hostState
+}{state: map[string]*hostState{}}
+
+func hostFor(host string) *hostState {
+	host = strings.ToLower(host)
+	state, ok := hosts.state[host]
+	if !ok {
+		state = &hostState{}
+		hosts.state[host] = state
+	}
+	return state
+}
+
+// waitForHost blocks until a request to host is allowed by interval and
+// reserves the slot. It fails straight away when the host is deferred,
+// and when the slot comes after ctx's deadline: a turn that cannot be waited
+// for is a deferral, not a failed fetch.
+func waitForHost(ctx context.Context, host string, interval time.Duration) error {
+	hosts.Lock()
+	state := hostFor(host)
+	now := time.Now()
+	if now.Before(state.blockedUntil) {
+		hosts.Unlock()
+		return &HostDeferredError{Host: host, Until: state.blockedUntil}
+	}
+	start := now
+	if state.nextRequest.After(start) {
+		start = state.nextRequest
+	}
+	if deadline, ok := ctx.Deadline(); ok && !start.Before(deadline) {
+		hosts.Unlock()
+		return &HostDeferredError{Host: host, Until: start}
+	}
+	state.nextRequest = start.Add(interval)
+	hosts.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+// deferHost records a 429 or 503 response, blocking the host until the time
+// its Retry-After header names.
+func deferHost(host string, resp *http.Response) *HostDeferredError {
+	until, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok {
+		until = time.Now().Add(DefaultRetryAfter)
+	}
+
+	hosts.Lock()
+	state := hostFor(host)
+	if until.After(state.blockedUntil) {
+		state.blockedUntil = until
+	}
+	hosts.Unlock()
+
+	return &HostDeferredError{Host: host, Until: until, StatusCode: resp.StatusCode}
+}
+
+// parseRetryAfter reads a Retry-After value, given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return time.Time{}, false
+		}
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		// http.ParseTime returns UTC; next_fetch_at is stored in local
+		// time like every other timestamp.
+		return at.Local(), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,30 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{"120", now.Add(2 * time.Minute), true},
+		{" 0 ", now, true},
+		{"Tue, 02 Jan 2024 15:10:05 GMT", time.Date(2024, 1, 2, 15, 10, 5, 0, time.UTC), true},
+		{"Tuesday, 02-Jan-24 15:10:05 GMT", time.Date(2024, 1, 2, 15, 10, 5, 0, time.UTC), true},
+		{"-5", time.Time{}, false},
+		{"1.5", time.Time{}, false},
+		{"soon", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
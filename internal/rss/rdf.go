@@ -0,0 +1,106 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const (
+	rdfNamespace        = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	rss10Namespace      = "http://purl.org/rss/1.0/"
+	dublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+)
+
+// rdfChannel is the channel of an RSS 1.0 document. Unlike RSS 2.0 the
+// items are its siblings under rdf:RDF, and dates come from Dublin Core.
+// Core fields are tagged with the RSS 1.0 namespace, so a <dc:title> cannot
+// stand in for the <title>.
+type rdfChannel struct {
+	Title       string `xml:"http://purl.org/rss/1.0/ title"`
+	Link        string `xml:"http://purl.org/rss/1.0/ link"`
+	Description string `xml:"http://purl.org/rss/1.0/ description"`
+	Language    string `xml:"http://purl.org/dc/elements/1.1/ language"`
+	Image       struct {
+		Resource string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# resource,attr"`
+	} `xml:"http://purl.org/rss/1.0/ image"`
+}
+
+type rdfItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Base        string   `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Title       string   `xml:"http://purl.org/rss/1.0/ title"`
+	Link        string   `xml:"http://purl.org/rss/1.0/ link"`
+	Description string   `xml:"http://purl.org/rss/1.0/ description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+func parseRDF(decoder *xml.Decoder, sink *itemSink) error {
+	rssFeed := sink.feed
+	err := eachChild(decoder, func(start xml.StartElement) error {
+		if start.Name.Space != rss10Namespace {
+			return decoder.Skip()
+		}
+		switch start.Name.Local {
+		case "channel":
+			var channel rdfChannel
+			if err := decoder.DecodeElement(&channel, &start); err != nil {
+				return err
+			}
+			rssFeed.Channel.Title = channel.Title
+			rssFeed.Channel.Link = strings.TrimSpace(channel.Link)
+			rssFeed.Channel.Description = channel.Description
+			rssFeed.Channel.Language = channel.Language
+			if rssFeed.Channel.Image == "" {
+				rssFeed.Channel.Image = strings.TrimSpace(channel.Image.Resource)
+			}
+			return nil
+		case "image":
+			var image rdfImage
+			if err := decoder.DecodeElement(&image, &start); err != nil {
+				return err
+			}
+			rssFeed.Channel.Image = strings.TrimSpace(image.URL)
+			return nil
+		case "item":
+			var item rdfItem
+			if err := decoder.DecodeElement(&item, &start); err != nil {
+				return err
+			}
+			return sink.add(item.item())
+		}
+		return decoder.Skip()
+	})
+	if err != nil {
+		return fmt.Errorf("%w: failed to decode rdf data", err)
+	}
+	return nil
+}
+
+func (item rdfItem) item() RSSItem {
+	guid := item.About
+	if guid == "" {
+		guid = item.Link
+	}
+
+	return RSSItem{
+		Title:       item.Title,
+		Link:        strings.TrimSpace(item.Link),
+		Description: item.Description,
+		Content:     item.Content,
+		PubDate:     item.Date,
+		Guid:        strings.TrimSpace(guid),
+		Creator:     item.Creator,
+		Categories:  item.Subjects,
+		Base:        item.Base,
+	}
+}
+
+// rdfImage is the <image> of an RSS 1.0 document, of which only the URL is
+// used.
+type rdfImage struct {
+	URL string `xml:"http://purl.org/rss/1.0/ url"`
+}
@@ -0,0 +1,50 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRDF(t *testing.T) {
+	feed, err := ParseFeed("application/rdf+xml", "https://example.com/index.rdf", strings.NewReader(`<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel rdf:about="https://example.com/">
+    <title>R</title>
+    <dc:title>DC</dc:title>
+    <link>https://example.com/</link>
+    <description>Channel</description>
+  </channel>
+  <image rdf:about="https://example.com/logo.png">
+    <url>https://example.com/logo.png</url>
+  </image>
+  <item rdf:about="https://example.com/posts/1" xml:base="https://example.com/posts/">
+    <title>Item</title>
+    <dc:title>DC item</dc:title>
+    <link>1</link>
+    <dc:date>2024-01-02T15:04:05Z</dc:date>
+    <dc:creator>Ann</dc:creator>
+  </item>
+</rdf:RDF>`), nil)
+	if err != nil {
+		t.Fatalf("ParseFeed: %v", err)
+	}
+	if feed.Channel.Title != "R" {
+		t.Errorf("channel Title = %q, want %q", feed.Channel.Title, "R")
+	}
+	if feed.Channel.Image != "https://example.com/logo.png" {
+		t.Errorf("channel Image = %q", feed.Channel.Image)
+	}
+	if len(feed.Channel.Item) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Channel.Item))
+	}
+	item := feed.Channel.Item[0]
+	if item.Title != "Item" {
+		t.Errorf("Title = %q, want %q", item.Title, "Item")
+	}
+	if item.Link != "https://example.com/posts/1" {
+		t.Errorf("Link = %q, want it resolved against the item's xml:base", item.Link)
+	}
+	if item.PubDate != "2024-01-02T15:04:05Z" || item.Creator != "Ann" {
+		t.Errorf("Dublin Core fields = %q, %q", item.PubDate, item.Creator)
+	}
+}
@@ -0,0 +1,174 @@
+package rss
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// robotsTTL is how long a host's robots.txt is cached.
+const robotsTTL = 24 * time.Hour
+
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsRules are the Allow and Disallow lines of the robots.txt group that
+// applies to gator. No rules means everything is allowed.
+type robotsRules struct {
+	rules []robotsRule
+}
+
+// robotsAllow fetches, or takes from the cache, the robots.txt of the feed's
+// host and reports whether gator may fetch the feed.
+func (f *HTTPFetcher) robotsAllow(ctx context.Context, u *url.URL) error {
+	hosts.Lock()
+	state := hostFor(u.Host)
+	rules := state.robots
+	fresh := rules != nil && time.Since(state.robotsAt) < robotsTTL
+	hosts.Unlock()
+
+	if !fresh {
+		rules = f.fetchRobots(ctx, u)
+		hosts.Lock()
+		state.robots = rules
+		state.robotsAt = time.Now()
+		hosts.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if !rules.allowed(path) {
+		return fmt.Errorf("%w: %s", ErrDisallowedByRobots, u)
+	}
+	return nil
+}
+
+// fetchRobots downloads and parses robots.txt. A missing or unreachable file
+// allows everything.
+func (f *HTTPFetcher) fetchRobots(ctx context.Context, u *url.URL) *robotsRules {
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return &robotsRules{}
+	}
+	req.Header.Set("User-Agent", "gator")
+
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return &robotsRules{}
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &robotsRules{}
+	}
+
+	body, err := readBody(resp, f.MaxBodySize)
+	if err != nil {
+		return &robotsRules{}
+	}
+	return parseRobots(body, "gator")
+}
+
+// parseRobots keeps the rules of the group naming agent, or of the "*" group
+// when no group names it.
+func parseRobots(r io.Reader, agent string) *robotsRules {
+	var specific, wildcard []robotsRule
+	var foundSpecific, matchSpecific, matchWildcard, inRules bool
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// A user-agent line after rules starts a new group.
+			if inRules {
+				matchSpecific, matchWildcard, inRules = false, false, false
+			}
+			switch strings.ToLower(value) {
+			case agent:
+				matchSpecific, foundSpecific = true, true
+			case "*":
+				matchWildcard = true
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{pattern: value, allow: key == "allow"}
+			if matchSpecific {
+				specific = append(specific, rule)
+			}
+			if matchWildcard {
+				wildcard = append(wildcard, rule)
+			}
+		}
+	}
+
+	if foundSpecific {
+		return &robotsRules{rules: specific}
+	}
+	return &robotsRules{rules: wildcard}
+}
+
+// allowed applies the longest matching rule, preferring Allow on a tie.
+func (r *robotsRules) allowed(path string) bool {
+	allow, longest := true, -1
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allow, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches a path against a robots.txt pattern, where "*" matches
+// any run of characters and a trailing "$" anchors the end of the path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
@@ -0,0 +1,62 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRobotsMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/private", "/private/feed.xml", true},
+		{"/private", "/pub", false},
+		{"/", "/anything", true},
+		{"*", "/anything", true},
+		{"/*.pdf", "/docs/a.pdf?dl=1", true},
+		{"/*.pdf$", "/docs/a.pdf", true},
+		{"/*.pdf$", "/docs/a.pdf?dl=1", false},
+		{"/feed$", "/feed", true},
+		{"/feed$", "/feed/", false},
+		{"/a*b*c", "/aXbYc", true},
+		{"/a*b*c", "/acb", false},
+	}
+	for _, tt := range tests {
+		if got := robotsMatch(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("robotsMatch(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRobotsAllowed(t *testing.T) {
+	tests := []struct {
+		name   string
+		robots string
+		path   string
+		want   bool
+	}{
+		{"longer allow wins", "User-agent: *\nDisallow: /private/\nAllow: /private/feed.xml", "/private/feed.xml", true},
+		{"disallowed", "User-agent: *\nDisallow: /private/\nAllow: /private/feed.xml", "/private/other", false},
+		{"longer disallow wins", "User-agent: *\nAllow: /blog\nDisallow: /blog/drafts", "/blog/drafts/1", false},
+		{"allow wins a tie", "User-agent: *\nDisallow: /tie\nAllow: /tie", "/tie", true},
+		{"wildcard rule", "User-agent: *\nDisallow: /*.pdf$", "/docs/a.pdf", false},
+		{"anchored wildcard rule", "User-agent: *\nDisallow: /*.pdf$", "/docs/a.pdf?dl=1", true},
+		{"no matching rule", "User-agent: *\nDisallow: /private/", "/public", true},
+		{"empty disallow", "User-agent: *\nDisallow:", "/anything", true},
+		{"own group over the wildcard one", "User-agent: gator\nDisallow: /feeds\n\nUser-agent: *\nDisallow: /", "/other", true},
+		{"own group rules", "User-agent: gator\nDisallow: /feeds\n\nUser-agent: *\nDisallow: /", "/feeds/1", false},
+		{"shared group", "User-agent: other\nUser-agent: Gator\nDisallow: /x", "/x/1", false},
+		{"other agent only", "User-agent: other\nDisallow: /", "/feed", true},
+		{"comments", "User-agent: * # everyone\nDisallow: /tmp # scratch", "/tmp/1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(tt.robots), "gator")
+			if got := rules.allowed(tt.path); got != tt.want {
+				t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package rss
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// SanitizeHTML reduces an HTML fragment from a feed to an allowlist of
+// structural and inline formatting tags, with only href, src, alt and title
+// attributes. Scripts, styles, embeds, event handlers, javascript: links and
+// tracking pixels are dropped. Links are resolved against base when it is
+// set and left relative otherwise.
+func SanitizeHTML(fragment string, base *url.URL) string {
+	if strings.TrimSpace(fragment) == "" {
+		return ""
+	}
+
+	nodes, err := nethtml.ParseFragment(strings.NewReader(fragment), &nethtml.Node{
+		Type:     nethtml.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return html.EscapeString(fragment)
+	}
+
+	var b strings.Builder
+	for _, n := range nodes {
+		renderClean(&b, n, base)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// cleanChannel normalizes the channel of a decoded feed: its title and
+// description are plain text and its links are made absolute. feedURL is
+// where the feed was read from and may be nil.
+func cleanChannel(rssFeed *RSSFeed, feedURL *url.URL) {
+	rssFeed.Channel.Title = html.UnescapeString(rssFeed.Channel.Title)
+	rssFeed.Channel.Description = html.UnescapeString(rssFeed.Channel.Description)
+	resolveLinks(rssFeed, feedURL)
+}
+
+// cleanItem normalizes a decoded item: its title is plain text, its
+// description and content are HTML and get sanitized, after conversion when
+// the source was plain text, and every link is made absolute. channelBase is
+// the base itemBase returns for the item's channel.
+func cleanItem(item *RSSItem, channelBase *url.URL) {
+	item.received = &receivedFields{
+		Link:        item.Link,
+		Title:       item.Title,
+		Description: item.Description,
+		Content:     item.Content,
+	}
+	base := resolveItemLinks(item, channelBase)
+	item.Title = html.UnescapeString(item.Title)
+	item.Author = html.UnescapeString(item.Author)
+	item.Creator = html.UnescapeString(item.Creator)
+	for j := range item.Categories {
+		item.Categories[j] = html.UnescapeString(item.Categories[j])
+	}
+	item.Description = SanitizeHTML(itemMarkup(item.Description, item.plainDescription), base)
+	item.Content = SanitizeHTML(itemMarkup(item.Content, item.plainContent), base)
+}
+
+// itemMarkup returns an item's description or content as HTML, converting
+// it from plain text when plain is set.
+func itemMarkup(s string, plain bool) string {
+	if plain {
+		return textHTML(s)
+	}
+	return unescapeMarkup(s)
+}
+
+// textHTML turns plain text into HTML: it is escaped, blank lines separate
+// paragraphs and single newlines become line breaks.
+func textHTML(text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, paragraph := range blankLines.Split(text, -1) {
+		if paragraph = strings.TrimSpace(paragraph); paragraph == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>"))
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
+
+var blankLines = regexp.MustCompile(`\n[ \t]*\n`)
+
+// unescapeMarkup undoes the double escaping some feeds apply, where the
+// decoded description still reads "&lt;p&gt;" rather than "<p>".
+func unescapeMarkup(s string) string {
+	if !strings.Contains(s, "<") && strings.Contains(s, "&lt;") {
+		return html.UnescapeString(s)
+	}
+	return s
+}
@@ -0,0 +1,82 @@
+package rss
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// shingleSize is how many consecutive words make up one SimHash feature.
+// Shingles rather than single words keep two texts on the same topic but in
+// different words from looking alike.
+const shingleSize = 3
+
+// SimHash is a 64-bit similarity fingerprint of the item's title and text.
+// Unlike ContentHash, near-identical items, such as the same wire story with
+// a different headline or closing line, get fingerprints that differ in only
+// a few bits; see SimHashDistance. It is 0 when the item has no words.
+func (item RSSItem) SimHash() uint64 {
+	body := item.Content
+	if body == "" {
+		body = item.Description
+	}
+	return SimHash(item.Title + "\n\n" + htmlText(body))
+}
+
+// SimHash fingerprints text by its word shingles, lowercased and stripped of
+// punctuation.
+func SimHash(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+	size := min(shingleSize, len(words))
+
+	var weights [64]int
+	for i := 0; i+size <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+size], " ")))
+		sum := h.Sum64()
+		for bit := range weights {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint
+}
+
+// SimHashDistance is the number of bits two fingerprints differ in: about 32
+// for unrelated texts, and single digits for copies of the same text.
+func SimHashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// htmlText returns the text of an HTML fragment, as paragraphs separated by
+// blank lines.
+func htmlText(fragment string) string {
+	doc, err := html.Parse(strings.NewReader(fragment))
+	if err != nil {
+		return fragment
+	}
+	for n := doc.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == html.ElementNode {
+			return plainText(n)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+)
+
+const wireStory = `The city council voted on Tuesday to approve a new budget that expands bus service across the northern districts, adds two hundred teachers to public schools and raises the tax on vacant homes.
+
+The mayor said the plan would be paid for without cutting other services, while critics argued the spending was too high for a year of slowing growth and warned that the vacant homes tax would be hard to collect.
+
+Under the plan, buses on the four busiest northern routes will run every ten minutes during the day, and a new overnight line will link the hospital district with the central station. The transit agency expects to hire sixty drivers before the autumn timetable starts.
+
+The teachers will be placed first in the schools with the largest classes, the education office said, and the district will open applications next month. Unions welcomed the hiring but said pay remained the main reason teachers leave the city.
+
+The budget takes effect in July after a final reading next week.`
+
+func TestSimHashDistance(t *testing.T) {
+	story := SimHash("Council approves budget\n\n" + wireStory)
+	tests := []struct {
+		name    string
+		text    string
+		minDist int
+		maxDist int
+	}{
+		{"same text", "Council approves budget\n\n" + wireStory, 0, 0},
+		{"case and punctuation", strings.ToUpper(strings.NewReplacer(",", "", ".", " ...").Replace("Council approves budget\n\n" + wireStory)), 0, 0},
+		{"other headline and closing line", "City passes spending plan with more buses\n\n" + wireStory + "\n\nReporting by Jane Doe; editing by John Roe.", 1, 6},
+		{"unrelated story", "Comet visible this weekend\n\nA rare comet will be visible to the naked eye this weekend, astronomers said, as it makes its closest approach to the sun in more than six thousand years. Observers in the northern hemisphere should look low in the western sky shortly after sunset, ideally from a dark site away from city lights, and binoculars will help pick out its faint tail.", 20, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance := SimHashDistance(story, SimHash(tt.text))
+			if distance < tt.minDist || distance > tt.maxDist {
+				t.Errorf("distance = %d, want %d to %d", distance, tt.minDist, tt.maxDist)
+			}
+		})
+	}
+}
+
+func TestSimHashNoWords(t *testing.T) {
+	for _, text := range []string{"", "   ", "... --- !!!"} {
+		if got := SimHash(text); got != 0 {
+			t.Errorf("SimHash(%q) = %x, want 0", text, got)
+		}
+	}
+}
+
+func TestItemSimHashUsesText(t *testing.T) {
+	item := RSSItem{Title: "Council approves budget", Content: "<p>" + strings.ReplaceAll(wireStory, "\n\n", "</p><p>") + "</p>"}
+	if got, want := item.SimHash(), SimHash("Council approves budget\n\n"+wireStory); got != want {
+		t.Errorf("item SimHash = %x, want %x as for the text without markup", got, want)
+	}
+}
@@ -0,0 +1,401 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Severity ranks a validation diagnostic. Errors make gator lose data or
+// fail the fetch; warnings are deviations it works around.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "info"
+}
+
+// Diagnostic is one finding of Validate. Check names the kind of finding:
+// http, encoding, format, required, date, link, guid or size.
+type Diagnostic struct {
+	Severity Severity
+	Check    string
+	// Item is the 1-based position of the item the finding is about, or 0
+	// when it is about the feed as a whole.
+	Item    int
+	Message string
+}
+
+// ValidationReport describes a feed as Validate found it.
+type ValidationReport struct {
+	URL         string
+	Format      string
+	Encoding    string
+	StatusCode  int
+	ContentType string
+	Size        int
+	Items       int
+	Diagnostics []Diagnostic
+}
+
+func (r *ValidationReport) add(severity Severity, check string, item int, format string, args ...any) {
+	r.Diagnostics = append(r.Diagnostics, Diagnostic{
+		Severity: severity,
+		Check:    check,
+		Item:     item,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Count returns how many diagnostics have the given severity.
+func (r *ValidationReport) Count(severity Severity) int {
+	var n int
+	for _, diagnostic := range r.Diagnostics {
+		if diagnostic.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// maxItemBytes is the size of an item's description and content above which
+// it is reported as oversized.
+const maxItemBytes = 100 << 10
+
+// Validate fetches the feed at target, an http(s) or file URL or a local
+// path, through fetcher and checks it the way gator will read it. Problems
+// with the feed itself are reported as diagnostics; the error is only for
+// targets that cannot be read at all.
+func Validate(ctx context.Context, fetcher Fetcher, target string) (*ValidationReport, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 {
+		// A plain path, or a Windows drive letter parsed as a scheme.
+		path, err := filepath.Abs(target)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid feed path", err)
+		}
+		u = &url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	}
+	report := &ValidationReport{URL: u.String()}
+
+	// Collecting the items, rather than visiting them, keeps the document as
+	// received for the checks below.
+	result, err := fetcher.Fetch(ctx, report.URL, Validators{}, nil)
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		result = parseErr.Result
+	} else if err != nil {
+		return report, validateFetchError(report, err)
+	}
+	report.StatusCode = result.StatusCode
+	report.ContentType = result.Header.Get("Content-Type")
+	for _, redirect := range result.Redirects {
+		severity := SeverityInfo
+		if redirect.Permanent() {
+			severity = SeverityWarning
+		}
+		report.add(severity, "http", 0, "redirected with %d from %s to %s", redirect.StatusCode, redirect.From, redirect.To)
+	}
+	if result.StatusCode != 0 {
+		validateCaching(ctx, fetcher, report, result)
+	}
+
+	data := result.Body
+	report.Size = len(data)
+	validateEncoding(report, data)
+	feed, err := validateFormat(report, data)
+	if err != nil {
+		report.add(SeverityError, "format", 0, "feed does not parse: %v", err)
+		return report, nil
+	}
+	report.Items = len(feed.Channel.Item)
+	validateChannel(report, feed)
+	validateItems(report, feed)
+	return report, nil
+}
+
+// validateFetchError reports a fetch that got no feed body to check. Error
+// statuses and oversized feeds are diagnostics; other errors are returned.
+func validateFetchError(report *ValidationReport, err error) error {
+	var statusErr *StatusError
+	var deferred *HostDeferredError
+	switch {
+	case errors.As(err, &statusErr):
+		report.StatusCode = statusErr.StatusCode
+		report.add(SeverityError, "http", 0, "server answered %s", statusErr.Status)
+	case errors.As(err, &deferred) && deferred.StatusCode != 0:
+		report.StatusCode = deferred.StatusCode
+		report.add(SeverityError, "http", 0, "server answered %d and asked to wait until %s", deferred.StatusCode, deferred.Until.Local().Format(time.RFC3339))
+	case errors.Is(err, ErrBodyTooLarge):
+		report.add(SeverityError, "size", 0, "%v (max_feed_bytes)", err)
+	default:
+		return err
+	}
+	return nil
+}
+
+// validateCaching reports the caching headers of a feed response and checks
+// that the validators it carries get a 304 when sent back.
+func validateCaching(ctx context.Context, fetcher Fetcher, report *ValidationReport, result *FetchResult) {
+	validators := result.Validators
+	for _, header := range []string{"ETag", "Last-Modified", "Cache-Control", "Expires"} {
+		if value := result.Header.Get(header); value != "" {
+			report.add(SeverityInfo, "http", 0, "%s: %s", header, value)
+		}
+	}
+	if validators == (Validators{}) {
+		report.add(SeverityWarning, "http", 0, "no ETag or Last-Modified header, so every fetch downloads the whole feed")
+		return
+	}
+	if validators.LastModified != "" {
+		if _, err := http.ParseTime(validators.LastModified); err != nil {
+			report.add(SeverityWarning, "http", 0, "Last-Modified is not an HTTP date: %q", validators.LastModified)
+		}
+	}
+
+	recheckURL := report.URL
+	if len(result.Redirects) > 0 {
+		recheckURL = result.Redirects[len(result.Redirects)-1].To
+	}
+	recheck, err := fetcher.Fetch(ctx, recheckURL, validators, func(RSSItem) error { return nil })
+	var statusErr *StatusError
+	switch {
+	case errors.As(err, &statusErr):
+		report.add(SeverityWarning, "http", 0, "conditional request answered %d instead of 304 Not Modified, so unchanged feeds are downloaded again", statusErr.StatusCode)
+	case err != nil:
+		report.add(SeverityWarning, "http", 0, "conditional request failed: %v", err)
+	case !recheck.NotModified:
+		report.add(SeverityWarning, "http", 0, "conditional request answered %d instead of 304 Not Modified, so unchanged feeds are downloaded again", recheck.StatusCode)
+	case len(recheck.Redirects) > 0:
+		report.add(SeverityInfo, "http", 0, "conditional request answered 304 after %d redirects", len(recheck.Redirects))
+	}
+}
+
+// mojibake matches the usual traces of UTF-8 text decoded as Latin-1 or
+// Windows-1252 before it was put in the feed: "Ã©" for "é", "â€™" for "’".
+var mojibake = regexp.MustCompile(`Ã[\x{80}-\x{BF}]|â€`)
+
+// validateEncoding works out the charset the way utf8Reader does and reports
+// where the declarations disagree with each other or with the bytes.
+func validateEncoding(report *ValidationReport, data []byte) {
+	headerLabel := contentTypeCharset(report.ContentType)
+	var declLabel string
+	if match := xmlEncodingDecl.FindSubmatch(data[:min(len(data), sniffLen)]); match != nil {
+		declLabel = string(match[1])
+	}
+	valid := utf8.Valid(data)
+
+	var headerName, declName string
+	if headerLabel != "" {
+		if _, headerName = charset.Lookup(headerLabel); headerName == "" {
+			report.add(SeverityWarning, "encoding", 0, "unknown charset %q in Content-Type", headerLabel)
+		}
+	}
+	if declLabel != "" {
+		if _, declName = charset.Lookup(declLabel); declName == "" {
+			report.add(SeverityError, "encoding", 0, "unsupported encoding %q in the XML declaration", declLabel)
+			return
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("\xef\xbb\xbf")):
+		report.Encoding = "utf-8 (byte order mark)"
+	case bomEncoding(data) != nil:
+		report.Encoding = "utf-16 (byte order mark)"
+	case headerName != "" && (headerName != "utf-8" || valid):
+		report.Encoding = headerName + " (Content-Type)"
+	case declName != "" && (declName != "utf-8" || valid):
+		report.Encoding = declName + " (XML declaration)"
+	case valid:
+		report.Encoding = "utf-8"
+	default:
+		report.Encoding = "windows-1252 (guessed)"
+	}
+
+	if headerName != "" && declName != "" && headerName != declName {
+		report.add(SeverityWarning, "encoding", 0, "Content-Type says %s but the XML declaration says %s", headerName, declName)
+	}
+	if !valid && bomEncoding(data) == nil && (headerName == "utf-8" || declName == "utf-8" || (headerName == "" && declName == "")) {
+		offset := len(data)
+		for i := 0; i < len(data); {
+			r, size := utf8.DecodeRune(data[i:])
+			if r == utf8.RuneError && size == 1 {
+				offset = i
+				break
+			}
+			i += size
+		}
+		report.add(SeverityWarning, "encoding", 0, "body is not valid UTF-8 (first bad byte at offset %d), read as %s", offset, report.Encoding)
+	}
+
+	r, err := utf8Reader(report.ContentType, data[:min(len(data), sniffLen)], bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	text, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
+	if n := bytes.Count(text, []byte("�")); n > 0 {
+		report.add(SeverityWarning, "encoding", 0, "replacement characters (U+FFFD) in the decoded text: %d", n)
+	}
+	if n := len(mojibake.FindAll(text, -1)); n > 0 {
+		report.add(SeverityWarning, "encoding", 0, "sequences that look like UTF-8 decoded twice, such as \"Ã©\" for \"é\": %d", n)
+	}
+}
+
+// validateFormat names the feed format and decodes the feed, without the
+// link resolution and sanitizing that would hide problems from the checks.
+func validateFormat(report *ValidationReport, data []byte) (*RSSFeed, error) {
+	head := data[:min(len(data), sniffLen)]
+	if isJSONFeed(report.ContentType, head) {
+		report.Format = "JSON Feed"
+	} else if r, err := utf8Reader(report.ContentType, head, bytes.NewReader(data)); err == nil {
+		if root, err := rootElement(newXMLDecoder(r)); err == nil {
+			report.Format = xmlFormat(root)
+		}
+	}
+	if report.Format == "" {
+		report.Format = "unknown"
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(report.ContentType)
+	switch {
+	case mediaType == "":
+	case strings.HasSuffix(mediaType, "xml"), strings.HasSuffix(mediaType, "json"):
+	default:
+		report.add(SeverityWarning, "format", 0, "Content-Type %s is not a feed media type", mediaType)
+	}
+	return decodeFeed(report.ContentType, bytes.NewReader(data), &itemSink{})
+}
+
+func xmlFormat(root xml.StartElement) string {
+	switch {
+	case root.Name.Local == "feed" && root.Name.Space == atomNamespace:
+		return "Atom 1.0"
+	case root.Name.Local == "RDF" && root.Name.Space == rdfNamespace:
+		return "RSS 1.0 (RDF)"
+	case root.Name.Local == "rss":
+		for _, a := range root.Attr {
+			if a.Name.Local == "version" {
+				return "RSS " + a.Value
+			}
+		}
+		return "RSS (no version)"
+	}
+	return fmt.Sprintf("unknown (<%s> root element)", root.Name.Local)
+}
+
+func validateChannel(report *ValidationReport, feed *RSSFeed) {
+	channel := feed.Channel
+	if strings.TrimSpace(channel.Title) == "" {
+		report.add(SeverityError, "required", 0, "feed has no title")
+	}
+	if strings.TrimSpace(channel.Link) == "" {
+		report.add(SeverityWarning, "required", 0, "feed has no link to its website")
+	} else if !isAbsolute(channel.Link) {
+		report.add(SeverityWarning, "link", 0, "relative feed link %q", channel.Link)
+	}
+	if strings.TrimSpace(channel.Description) == "" && strings.HasPrefix(report.Format, "RSS") {
+		report.add(SeverityWarning, "required", 0, "feed has no description")
+	}
+	if len(channel.Item) == 0 {
+		report.add(SeverityWarning, "required", 0, "feed has no items")
+	}
+}
+
+var markupRef = regexp.MustCompile(`(?i)\s(?:href|src)\s*=\s*["']([^"']*)["']`)
+
+func validateItems(report *ValidationReport, feed *RSSFeed) {
+	guids := map[string]int{}
+	links := map[string]int{}
+	now := time.Now()
+	for i, item := range feed.Channel.Item {
+		n := i + 1
+
+		title := strings.TrimSpace(item.Title)
+		if title == "" && strings.TrimSpace(item.Description) == "" {
+			report.add(SeverityError, "required", n, "item has neither a title nor a description")
+		} else if title == "" {
+			report.add(SeverityError, "required", n, "item has no title, and gator skips untitled items")
+		}
+		if strings.TrimSpace(item.Link) == "" {
+			report.add(SeverityWarning, "required", n, "item has no link")
+		}
+
+		if guid := strings.TrimSpace(item.Guid); guid == "" {
+			report.add(SeverityWarning, "guid", n, "item has no guid, so it is identified by its link and title")
+		} else if first, ok := guids[guid]; ok {
+			report.add(SeverityError, "guid", n, "guid %q is also used by item %d, so one of them is never saved", guid, first)
+		} else {
+			guids[guid] = n
+		}
+		if link := strings.TrimSpace(item.Link); link != "" {
+			if first, ok := links[link]; ok {
+				report.add(SeverityWarning, "link", n, "link is the same as item %d's", first)
+			} else {
+				links[link] = n
+			}
+		}
+
+		if strings.TrimSpace(item.PubDate) == "" {
+			report.add(SeverityWarning, "date", n, "item has no publication date, so the fetch time is used")
+		} else if published, err := ParseDate(item.PubDate); err != nil {
+			report.add(SeverityError, "date", n, "unparseable date %q", item.PubDate)
+		} else if published.After(now.Add(24 * time.Hour)) {
+			report.add(SeverityWarning, "date", n, "date %q is in the future", item.PubDate)
+		}
+
+		if item.Link != "" && !isAbsolute(item.Link) {
+			report.add(SeverityWarning, "link", n, "relative link %q", item.Link)
+		}
+		for _, enclosure := range item.Enclosures {
+			if !isAbsolute(enclosure.URL) {
+				report.add(SeverityWarning, "link", n, "relative enclosure URL %q", enclosure.URL)
+			}
+		}
+		var relative int
+		for _, match := range markupRef.FindAllStringSubmatch(item.Description+item.Content, -1) {
+			if ref := strings.TrimSpace(match[1]); ref != "" && !strings.HasPrefix(ref, "#") && !isAbsolute(ref) {
+				relative++
+			}
+		}
+		if relative > 0 {
+			report.add(SeverityInfo, "link", n, "relative links or images in the item's HTML: %d", relative)
+		}
+
+		if size := len(item.Description) + len(item.Content); size > maxItemBytes {
+			report.add(SeverityWarning, "size", n, "item text is %d KiB", size>>10)
+		}
+	}
+}
+
+func isAbsolute(ref string) bool {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	return err == nil && u.IsAbs()
+}
@@ -0,0 +1,66 @@
+package rss
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateThroughFetcher(t *testing.T) {
+	fetcher := &FixtureFetcher{Dir: filepath.Join("testdata", "fixtures")}
+	report, err := Validate(context.Background(), fetcher, "https://blog.example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if report.StatusCode != 200 || report.Items != 1 {
+		t.Errorf("StatusCode = %d, Items = %d, want 200 and 1", report.StatusCode, report.Items)
+	}
+	if !strings.HasPrefix(report.ContentType, "application/rss+xml") {
+		t.Errorf("ContentType = %q", report.ContentType)
+	}
+	if report.Count(SeverityError) != 0 {
+		t.Errorf("errors in a valid feed: %+v", report.Diagnostics)
+	}
+	// The fixture replays the same 200 for the conditional request.
+	if !hasDiagnostic(report, "instead of 304 Not Modified") {
+		t.Errorf("conditional request not checked: %+v", report.Diagnostics)
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	feed := `<rss version="2.0"><channel><title>Local</title><item><title>One</title><pubDate>yesterday</pubDate></item></channel></rss>`
+	if err := os.WriteFile(path, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Validate(context.Background(), SchemeFetcher{"file": FileFetcher{}}, path)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if report.StatusCode != 0 || report.Size != len(feed) {
+		t.Errorf("StatusCode = %d, Size = %d, want 0 and %d", report.StatusCode, report.Size, len(feed))
+	}
+	if !hasDiagnostic(report, `unparseable date "yesterday"`) {
+		t.Errorf("bad date not reported: %+v", report.Diagnostics)
+	}
+
+	report, err = Validate(context.Background(), SchemeFetcher{"file": FileFetcher{MaxBodySize: 10}}, path)
+	if err != nil {
+		t.Fatalf("Validate over the size limit: %v", err)
+	}
+	if !hasDiagnostic(report, "max_feed_bytes") {
+		t.Errorf("oversized file not reported: %+v", report.Diagnostics)
+	}
+}
+
+func hasDiagnostic(report *ValidationReport, text string) bool {
+	for _, diagnostic := range report.Diagnostics {
+		if strings.Contains(diagnostic.Message, text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package rss
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// addLinkRel records the WebSub relevant links of a feed. Hubs are kept
+// unique; the first self link wins.
+func (feed *RSSFeed) addLinkRel(rel, href string) {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return
+	}
+
+	for _, r := range strings.Fields(rel) {
+		switch strings.ToLower(r) {
+		case "hub":
+			for _, hub := range feed.Channel.Hubs {
+				if hub == href {
+					return
+				}
+			}
+			feed.Channel.Hubs = append(feed.Channel.Hubs, href)
+		case "self":
+			if feed.Channel.Self == "" {
+				feed.Channel.Self = href
+			}
+		}
+	}
+}
+
+// linkHeaderRels parses RFC 8288 Link headers into their targets by rel,
+// e.g. `<https://hub.example/>; rel="hub"`.
+func linkHeaderRels(headers []string) map[string][]string {
+	rels := map[string][]string{}
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = strings.Trim(target, "<>")
+
+			for _, param := range parts[1:] {
+				key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				rel := strings.Trim(strings.TrimSpace(value), `"`)
+				rels[rel] = append(rels[rel], target)
+			}
+		}
+	}
+	return rels
+}
+
+func resolveAgainst(base *url.URL, href string) string {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil || base == nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// Subscribe asks a WebSub hub to push updates of topic to callback. The hub
+// answers 202 Accepted and confirms later by calling the callback with a
+// challenge, so success here only means the request was accepted.
+func Subscribe(ctx context.Context, hub, topic, callback, secret string, lease time.Duration) error {
+	form := url.Values{
+		"hub.mode":     {"subscribe"},
+		"hub.topic":    {topic},
+		"hub.callback": {callback},
+	}
+	if secret != "" {
+		form.Set("hub.secret", secret)
+	}
+	if lease > 0 {
+		form.Set("hub.lease_seconds", strconv.Itoa(int(lease.Seconds())))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 6*time.Second)
+
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed getting reqest: %w\nmethod: %v\nurl: %s", err, http.MethodPost, hub)
+	}
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := defaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed sending subscription request %w", err)
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("hub rejected subscription: HTTP error: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// VerifySignature checks a WebSub X-Hub-Signature header, "<method>=<hex>",
+// against the HMAC of body keyed with the subscription secret.
+func VerifySignature(signature, secret string, body []byte) bool {
+	method, digest, ok := strings.Cut(signature, "=")
+	if !ok {
+		return false
+	}
+
+	var newHash func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
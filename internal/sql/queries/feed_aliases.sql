@@ -0,0 +1,4 @@
+-- name: CreateFeedAlias :exec
+INSERT INTO feed_aliases (url, feed_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (url) DO UPDATE SET feed_id = EXCLUDED.feed_id;
@@ -14,8 +14,14 @@ RETURNING *;
 -- name: GetFeeds :many
 SELECT * FROM feeds;
 
+-- name: GetFeedById :one
+SELECT * FROM feeds WHERE id = $1;
+
 -- name: GetFeedId :one
-SELECT feeds.id FROM feeds WHERE url = $1;
+SELECT feeds.id FROM feeds WHERE feeds.url = $1
+UNION
+SELECT feed_aliases.feed_id FROM feed_aliases WHERE feed_aliases.url = $1
+LIMIT 1;
 
 -- name: MarkFeedFetched :exec
 UPDATE feeds
@@ -27,7 +33,83 @@ WHERE id = $1;
 -- name: GetNextFeedToFetch :one
 SELECT *
 FROM feeds
+WHERE disabled_at IS NULL
+  AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
 ORDER BY
   last_fetched_at ASC NULLS FIRST,
   id ASC
 LIMIT 1;
+
+-- name: SetFeedFullText :execrows
+UPDATE feeds
+SET
+  fetch_full_text = $2,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET
+  title = $2,
+  site_url = $3,
+  description = $4,
+  language = $5,
+  image_url = $6,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateFeedValidators :exec
+UPDATE feeds
+SET
+  etag = $2,
+  last_modified = $3,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateFeedUrl :exec
+UPDATE feeds
+SET
+  url = $2,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeferFeedsOnHost :execrows
+UPDATE feeds
+SET
+  next_fetch_at = GREATEST(COALESCE(next_fetch_at, sqlc.arg(until)::timestamp), sqlc.arg(until)::timestamp),
+  updated_at = NOW()
+WHERE disabled_at IS NULL
+  AND lower(substring(url from '^[A-Za-z][A-Za-z0-9+.-]*://([^/?#]+)')) = lower(sqlc.arg(host)::text);
+
+-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET
+  failure_count = $2,
+  last_error = $3,
+  next_fetch_at = $4,
+  disabled_at = $5,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET
+  failure_count = 0,
+  last_error = NULL,
+  next_fetch_at = NULL
+WHERE id = $1;
+
+-- name: GetDisabledFeeds :many
+SELECT * FROM feeds
+WHERE disabled_at IS NOT NULL
+ORDER BY disabled_at DESC;
+
+-- name: EnableFeed :execrows
+UPDATE feeds
+SET
+  failure_count = 0,
+  last_error = NULL,
+  next_fetch_at = NULL,
+  disabled_at = NULL,
+  updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,4 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, post_id) DO UPDATE SET read_at = EXCLUDED.read_at;
@@ -0,0 +1,3 @@
+-- name: CreatePostRevision :exec
+INSERT INTO post_revisions (post_id, title, description, content, content_hash)
+VALUES ($1, $2, $3, $4, $5);
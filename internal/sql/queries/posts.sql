@@ -1,5 +1,5 @@
 -- name: CreatePost :one
-INSERT INTO posts (created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content, guid, author, comments_url, content_hash, canonical_url, simhash, cluster_id)
 VALUES (
     $1,
     $2,
@@ -7,18 +7,114 @@ VALUES (
     $4,
     $5,
     $6,
-    $7
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16
 )
 RETURNING *;
 
+-- name: AdoptLegacyPostGuid :exec
+UPDATE posts
+SET guid = $3
+WHERE feed_id = $1 AND url = $2 AND guid LIKE 'legacy:%';
+
+-- name: FeedHasLegacyPosts :one
+SELECT EXISTS(SELECT 1 FROM posts WHERE feed_id = $1 AND guid LIKE 'legacy:%');
+
+-- name: GetClusterFeedNames :many
+SELECT DISTINCT feeds.name FROM posts
+JOIN feeds ON posts.feed_id = feeds.id
+JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE posts.cluster_id = $1 AND feed_follows.user_id = $2 AND posts.feed_id <> $3
+ORDER BY feeds.name;
+
+-- name: GetRecentPostFingerprints :many
+SELECT id, cluster_id, simhash FROM posts
+WHERE feed_id <> sqlc.arg(feed_id) AND simhash IS NOT NULL AND created_at >= sqlc.arg(since) AND duplicate_of IS NULL;
+
 -- name: GetUserPosts :many
-SELECT DISTINCT * FROM posts
+SELECT posts.*, feeds.name AS feed_name, post_reads.read_at FROM posts
 JOIN feeds ON posts.feed_id = feeds.id
 
 JOIN feed_follows ON feed_follows.feed_id = feeds.id
 
-WHERE feed_follows.user_id = $1
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND posts.duplicate_of IS NULL
+  AND (sqlc.narg(author)::text IS NULL OR posts.author ILIKE '%' || sqlc.narg(author) || '%')
+  AND (sqlc.narg(category)::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_categories
+    WHERE post_categories.post_id = posts.id
+      AND lower(post_categories.name) = lower(sqlc.narg(category))
+  ))
+  AND (NOT sqlc.arg(clusters)::boolean OR NOT EXISTS (
+    SELECT 1 FROM posts AS sibling
+    JOIN feed_follows AS sibling_follows ON sibling_follows.feed_id = sibling.feed_id
+    WHERE sibling_follows.user_id = feed_follows.user_id
+      AND sibling.cluster_id = posts.cluster_id
+      AND sibling.duplicate_of IS NULL
+      AND (sibling.published_at > posts.published_at
+        OR (sibling.published_at = posts.published_at AND sibling.id < posts.id))
+      AND (sqlc.narg(author)::text IS NULL OR sibling.author ILIKE '%' || sqlc.narg(author) || '%')
+      AND (sqlc.narg(category)::text IS NULL OR EXISTS (
+        SELECT 1 FROM post_categories
+        WHERE post_categories.post_id = sibling.id
+          AND lower(post_categories.name) = lower(sqlc.narg(category))
+      ))
+  ))
+
+ORDER BY posts.published_at DESC
+
+LIMIT sqlc.arg('limit');
+
+-- name: GetPostByGuid :one
+SELECT * FROM posts
+WHERE feed_id = $1 AND guid = $2;
+
+-- name: SetPostContentHash :exec
+UPDATE posts
+SET content_hash = $2
+WHERE id = $1;
+
+-- name: UpdatePostContent :exec
+UPDATE posts
+SET
+  title = $2,
+  description = $3,
+  content = $4,
+  content_hash = $5,
+  content_updated_at = NOW(),
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdatePostCanonicalUrl :execrows
+UPDATE posts
+SET canonical_url = $2, updated_at = NOW()
+WHERE id = $1 AND NOT EXISTS (
+    SELECT 1 FROM posts AS other
+    WHERE other.feed_id = posts.feed_id AND other.canonical_url = $2 AND other.id <> posts.id
+);
 
-ORDER BY published_at DESC
+-- name: MarkPostDuplicate :execrows
+UPDATE posts
+SET duplicate_of = original.id, updated_at = NOW()
+FROM posts AS original
+WHERE posts.id = $1 AND original.feed_id = posts.feed_id AND original.canonical_url = $2 AND original.id <> posts.id;
 
-LIMIT $2;
+-- name: UpdatePostFullText :exec
+UPDATE posts
+SET
+  full_content = $2,
+  full_text = $3,
+  word_count = $4,
+  reading_minutes = $5,
+  updated_at = NOW()
+WHERE id = $1;
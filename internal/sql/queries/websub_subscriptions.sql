@@ -0,0 +1,34 @@
+-- name: UpsertWebSubHub :exec
+INSERT INTO websub_subscriptions (feed_id, created_at, updated_at, hub_url, topic_url)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (feed_id) DO UPDATE
+SET
+  hub_url = EXCLUDED.hub_url,
+  topic_url = EXCLUDED.topic_url,
+  updated_at = EXCLUDED.updated_at,
+  lease_expires_at = CASE
+    WHEN websub_subscriptions.hub_url = EXCLUDED.hub_url
+      AND websub_subscriptions.topic_url = EXCLUDED.topic_url
+    THEN websub_subscriptions.lease_expires_at
+  END;
+
+-- name: GetWebSubSubscriptions :many
+SELECT * FROM websub_subscriptions;
+
+-- name: GetWebSubSubscription :one
+SELECT * FROM websub_subscriptions WHERE feed_id = $1;
+
+-- name: MarkWebSubRequested :exec
+UPDATE websub_subscriptions
+SET
+  secret = $2,
+  requested_at = NOW(),
+  updated_at = NOW()
+WHERE feed_id = $1;
+
+-- name: ConfirmWebSubLease :exec
+UPDATE websub_subscriptions
+SET
+  lease_expires_at = $2,
+  updated_at = NOW()
+WHERE feed_id = $1;
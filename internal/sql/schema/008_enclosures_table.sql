@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE enclosures (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    post_url TEXT NOT NULL,
+    url TEXT NOT NULL,
+    mime_type TEXT,
+    length BIGINT,
+    duration_seconds INTEGER,
+    thumbnail_url TEXT,
+    UNIQUE (post_url, url),
+        FOREIGN KEY(post_url) REFERENCES posts(url) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS enclosures;
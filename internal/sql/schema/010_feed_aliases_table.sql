@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE feed_aliases (
+    url TEXT PRIMARY KEY,
+    feed_id UUID NOT NULL,
+    created_at TIMESTAMP,
+
+        FOREIGN KEY(feed_id) REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS feed_aliases;
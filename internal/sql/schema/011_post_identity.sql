@@ -0,0 +1,35 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN id UUID NOT NULL DEFAULT uuid_generate_v4();
+ALTER TABLE posts ADD PRIMARY KEY (id);
+
+-- Existing posts never stored their guid. They are marked as legacy and
+-- adopt the real guid the next time their feed serves them.
+ALTER TABLE posts ADD COLUMN guid TEXT;
+UPDATE posts SET guid = 'legacy:' || url;
+ALTER TABLE posts ALTER COLUMN guid SET NOT NULL;
+
+ALTER TABLE enclosures ADD COLUMN post_id UUID;
+UPDATE enclosures SET post_id = posts.id FROM posts WHERE posts.url = enclosures.post_url;
+DELETE FROM enclosures WHERE post_id IS NULL;
+ALTER TABLE enclosures ALTER COLUMN post_id SET NOT NULL;
+ALTER TABLE enclosures DROP COLUMN post_url;
+ALTER TABLE enclosures ADD CONSTRAINT enclosures_post_id_url_key UNIQUE (post_id, url);
+ALTER TABLE enclosures ADD FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE;
+
+-- The url uniqueness dates from when the table was still called browse.
+ALTER TABLE posts DROP CONSTRAINT browse_url_key;
+ALTER TABLE posts ADD CONSTRAINT posts_feed_id_guid_key UNIQUE (feed_id, guid);
+
+-- +goose Down
+ALTER TABLE posts DROP CONSTRAINT posts_feed_id_guid_key;
+ALTER TABLE posts ADD CONSTRAINT browse_url_key UNIQUE (url);
+
+ALTER TABLE enclosures ADD COLUMN post_url TEXT;
+UPDATE enclosures SET post_url = posts.url FROM posts WHERE posts.id = enclosures.post_id;
+ALTER TABLE enclosures ALTER COLUMN post_url SET NOT NULL;
+ALTER TABLE enclosures DROP COLUMN post_id;
+ALTER TABLE enclosures ADD CONSTRAINT enclosures_post_url_url_key UNIQUE (post_url, url);
+ALTER TABLE enclosures ADD FOREIGN KEY (post_url) REFERENCES posts(url) ON DELETE CASCADE;
+
+ALTER TABLE posts DROP COLUMN guid;
+ALTER TABLE posts DROP COLUMN id;
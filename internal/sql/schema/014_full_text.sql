@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN fetch_full_text BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE posts ADD COLUMN full_content TEXT;
+ALTER TABLE posts ADD COLUMN full_text TEXT;
+ALTER TABLE posts ADD COLUMN word_count INTEGER;
+ALTER TABLE posts ADD COLUMN reading_minutes INTEGER;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN reading_minutes;
+ALTER TABLE posts DROP COLUMN word_count;
+ALTER TABLE posts DROP COLUMN full_text;
+ALTER TABLE posts DROP COLUMN full_content;
+ALTER TABLE feeds DROP COLUMN fetch_full_text;
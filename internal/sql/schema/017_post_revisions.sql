@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN content_hash TEXT;
+ALTER TABLE posts ADD COLUMN content_updated_at TIMESTAMP;
+
+CREATE TABLE post_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    post_id UUID NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    content TEXT,
+    content_hash TEXT,
+        FOREIGN KEY(post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX post_revisions_post_id_idx ON post_revisions (post_id, created_at);
+
+CREATE TABLE post_reads (
+    user_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    read_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id),
+        FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+        FOREIGN KEY(post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS post_reads;
+DROP TABLE IF EXISTS post_revisions;
+ALTER TABLE posts DROP COLUMN content_updated_at;
+ALTER TABLE posts DROP COLUMN content_hash;
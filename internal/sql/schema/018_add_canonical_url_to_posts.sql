@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN canonical_url TEXT;
+
+-- Existing links were never canonicalized, so they are used as they are.
+-- Where a feed already holds the same link twice, only the oldest post gets
+-- it, to keep the constraint below satisfiable.
+UPDATE posts SET canonical_url = url
+WHERE url <> '' AND id IN (
+    SELECT DISTINCT ON (feed_id, url) id FROM posts
+    ORDER BY feed_id, url, created_at
+);
+
+ALTER TABLE posts ADD CONSTRAINT posts_feed_id_canonical_url_key UNIQUE (feed_id, canonical_url);
+
+-- +goose Down
+ALTER TABLE posts DROP CONSTRAINT posts_feed_id_canonical_url_key;
+ALTER TABLE posts DROP COLUMN canonical_url;
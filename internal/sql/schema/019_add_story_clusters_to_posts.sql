@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN simhash BIGINT;
+ALTER TABLE posts ADD COLUMN cluster_id UUID;
+
+-- Posts saved before clustering each form a story of their own.
+UPDATE posts SET cluster_id = id;
+
+CREATE INDEX posts_cluster_id_idx ON posts (cluster_id);
+CREATE INDEX posts_created_at_idx ON posts (created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS posts_created_at_idx;
+DROP INDEX IF EXISTS posts_cluster_id_idx;
+ALTER TABLE posts DROP COLUMN cluster_id;
+ALTER TABLE posts DROP COLUMN simhash;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Content hashes are now taken over the item as the feed sent it. Clearing
+-- the old ones makes each post's next fetch its new baseline, instead of an
+-- edit.
+UPDATE posts SET content_hash = NULL;
+
+-- +goose Down
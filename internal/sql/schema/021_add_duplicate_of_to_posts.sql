@@ -0,0 +1,7 @@
+-- +goose Up
+-- A post whose page turns out to have the canonical URL of another post in
+-- its feed is kept, so the next fetch still finds its guid, but hidden.
+ALTER TABLE posts ADD COLUMN duplicate_of UUID REFERENCES posts(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN duplicate_of;
@@ -11,6 +11,7 @@ import (
 	"github.com/mcoluomo/RSS-Aggregator/internal/cli"
 	"github.com/mcoluomo/RSS-Aggregator/internal/config"
 	"github.com/mcoluomo/RSS-Aggregator/internal/database"
+	"github.com/mcoluomo/RSS-Aggregator/internal/rss"
 )
 
 func main() {
@@ -28,7 +29,12 @@ func main() {
 	}
 	fmt.Printf("current user: %v\n", cfg.Current_user_name)
 
-	cfgState := &config.State{StConfig: &cfg, Db: dbQueries}
+	fetcher, err := newFetcher(cfg)
+	if err != nil {
+		log.Fatalf("error reading config: %v", err)
+	}
+
+	cfgState := &config.State{StConfig: &cfg, Db: dbQueries, Fetcher: fetcher}
 
 	cmds := &cli.Commands{Handlers: map[string]func(*config.State, cli.Command) error{}}
 	cmds.Register("login", cli.LoginHandler)
@@ -38,10 +44,15 @@ func main() {
 	cmds.Register("agg", cli.AggHandler)
 	cmds.Register("addfeed", cli.MiddlewareLoggedIn(cli.AddFeedHandler))
 	cmds.Register("feeds", cli.PrintFeedsHandler)
+	cmds.Register("disabled", cli.DisabledFeedsHandler)
+	cmds.Register("enable", cli.EnableFeedHandler)
+	cmds.Register("fulltext", cli.FullTextHandler)
 	cmds.Register("follow", cli.MiddlewareLoggedIn(cli.FollowHandler))
 	cmds.Register("following", cli.MiddlewareLoggedIn(cli.FeedFollowingHandler))
 	cmds.Register("unfollow", cli.MiddlewareLoggedIn(cli.UnfollowFeedFollow))
 	cmds.Register("browse", cli.BrowseFeedsHandler)
+	cmds.Register("websub", cli.WebSubHandler)
+	cmds.Register("validate", cli.ValidateHandler)
 
 	if len(os.Args) < 2 {
 		log.Fatalf("\n---------------------------------\nPlease provide <command> [arg]\n---------------------------------\n")
@@ -58,3 +69,27 @@ func main() {
 
 	fmt.Printf("current user: %v\n", cfg.Current_user_name)
 }
+
+// newFetcher serves file:// feeds from disk and web feeds over HTTP, or from
+// recorded fixtures when fixtures_dir is set.
+func newFetcher(cfg config.Config) (rss.Fetcher, error) {
+	httpFetcher, err := cfg.HTTPFetcher()
+	if err != nil {
+		return nil, err
+	}
+
+	var web rss.Fetcher = httpFetcher
+	if cfg.Fixtures_dir != "" {
+		fixtures := &rss.FixtureFetcher{Dir: cfg.Fixtures_dir, MaxBodySize: cfg.MaxFeedBytes()}
+		if cfg.Record_fixtures {
+			fixtures.Record = httpFetcher
+		}
+		web = fixtures
+	}
+
+	return rss.SchemeFetcher{
+		"http":  web,
+		"https": web,
+		"file":  rss.FileFetcher{MaxBodySize: cfg.MaxFeedBytes()},
+	}, nil
+}